@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	daynightcycle "github.com/brittonhayes/day-night-cycle"
+	"github.com/brittonhayes/day-night-cycle/internal"
+	"github.com/brittonhayes/day-night-cycle/plugins"
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig handles the "config" subcommands.
+func runConfig(configPath string, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: day-night-cycle config export|import|migrate|schema|capture|sync")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "export":
+		configExport(configPath, args[1:])
+	case "import":
+		configImport(configPath, args[1:])
+	case "migrate":
+		configMigrate(configPath)
+	case "capture":
+		configCapture(configPath, args[1:])
+	case "sync":
+		configSync(configPath)
+	case "schema":
+		// For yaml-language-server: save it and point a
+		// "# yaml-language-server: $schema=<path>" comment at it.
+		os.Stdout.Write(daynightcycle.ConfigSchema)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown config command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// configExport writes the config as a single normalized YAML document to
+// stdout or a file. With --resolved it is what this machine runs right
+// now: the active profile and saved location applied and entries for other
+// machines dropped.
+func configExport(configPath string, args []string) {
+	flags := flag.NewFlagSet("config export", flag.ExitOnError)
+	resolved := flags.Bool("resolved", false, "export the active profile, location, and this machine's entries only")
+	flags.Parse(args)
+
+	// Validate even a plain export, so a broken config isn't copied around.
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+	if *resolved {
+		cfg.Profiles = nil
+		cfg.Locations, cfg.LocationDetect = nil, ""
+	} else {
+		cfg, err = internal.Read(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(exitConfig)
+		}
+	}
+
+	cfg.Version = internal.ConfigVersion
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	data := buf.Bytes()
+
+	if flags.NArg() == 0 {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(flags.Arg(0), data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported config to %s\n", flags.Arg(0))
+}
+
+// configImport validates a config file and installs it at the config path,
+// keeping the previous config as config.yaml.bak.
+func configImport(configPath string, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: day-night-cycle config import <file>")
+		os.Exit(1)
+	}
+
+	if _, err := internal.Load(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s: %v\n", args[0], err)
+		os.Exit(exitConfig)
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if old, err := os.ReadFile(configPath); err == nil {
+		if err := os.WriteFile(configPath+".bak", old, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Previous config saved to %s.bak\n", configPath)
+	}
+
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported config to %s\n", configPath)
+}
+
+// configMigrate upgrades the config file to the current layout in place,
+// keeping the previous file as config.yaml.bak.
+func configMigrate(configPath string) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	migrated, changed, err := internal.Migrate(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if !changed {
+		fmt.Printf("Config is already version %d\n", internal.ConfigVersion)
+		return
+	}
+
+	if err := os.WriteFile(configPath+".bak", data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(configPath, migrated, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Migrated config to version %d (previous saved to %s.bak)\n", internal.ConfigVersion, configPath)
+}
+
+// configCapture writes a starter config to stdout or a new file, with an
+// entry for each installed app whose current theme can be read. The system
+// appearance decides whether a theme is the day or night value.
+func configCapture(configPath string, args []string) {
+	var notes []string
+
+	isLight, err := internal.SystemIsLight()
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("Couldn't read the system appearance (%v), so current themes are night values.", err))
+	} else if isLight {
+		notes = append(notes, "The system is in light mode, so current themes are day values; fill in the night values.")
+	} else {
+		notes = append(notes, "The system is in dark mode, so current themes are night values; fill in the day values.")
+	}
+
+	cfg := internal.Config{Version: internal.ConfigVersion}
+	if existing, err := internal.Read(configPath); err == nil {
+		cfg.Location = existing.Location
+	} else if tz := internal.SystemTimezone(); tz != "" {
+		cfg.Location.Timezone = tz
+		notes = append(notes, "The location is estimated from your time zone; set latitude and longitude for exact times.")
+	} else {
+		cfg.Location.Timezone = "Local"
+		notes = append(notes, "Set location to your latitude, longitude, and time zone.")
+	}
+
+	candidates := []internal.ConfigPluginEntry{
+		// VS Code is themed by the cursor plugin pointed at its settings.
+		{Name: "cursor", Label: "vscode", PluginConfig: plugins.PluginConfig{
+			Custom: map[string]any{"path": "~/Library/Application Support/Code/User/settings.json"},
+		}},
+	}
+	names := slices.Sorted(maps.Keys(plugins.Registry))
+	for _, name := range names {
+		candidates = append(candidates, internal.ConfigPluginEntry{Name: name})
+	}
+
+	for _, entry := range candidates {
+		if !plugins.Detect(entry.Name, entry.PluginConfig) {
+			continue
+		}
+		day, night, ok, err := plugins.Capture(entry.Name, entry.PluginConfig, isLight)
+		if !ok {
+			continue
+		}
+		label := entry.Label
+		if label == "" {
+			label = entry.Name
+		}
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("%s: %v", label, err))
+		}
+		entry.Enabled = true
+		entry.Day, entry.Night = day, night
+		cfg.Plugins = append(cfg.Plugins, entry)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Captured from your apps' current themes by \"day-night-cycle config capture\".\n")
+	for _, note := range notes {
+		fmt.Fprintf(&buf, "# %s\n", note)
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) == 0 {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	// Never overwrite a config; config import installs it with a backup.
+	file, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err == nil {
+		_, err = file.Write(buf.Bytes())
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Captured a starter config to %s; review it, then \"day-night-cycle config import %s\"\n", args[0], args[0])
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/brittonhayes/day-night-cycle/internal"
+	"github.com/brittonhayes/day-night-cycle/plugins"
+	"github.com/fsnotify/fsnotify"
+)
+
+// daemon is the state of a running "daemon" command. Control requests are
+// handled on the same goroutine as ticks, so it needs no locking.
+type daemon struct {
+	configPath string
+
+	applied   bool
+	lastLight bool
+	lastDusk  bool
+
+	// forced is "light" or "dark" after a light/dark request, until the
+	// next transition.
+	forced string
+	paused bool
+
+	// stepping holds the labels of plugins stepped in their transition
+	// window on the last tick.
+	stepping map[string]bool
+}
+
+// daemonRequest is one command received on the control socket.
+type daemonRequest struct {
+	command string
+	reply   chan string
+	// sent is closed once the reply has been written to the client.
+	sent chan struct{}
+}
+
+// runDaemon stays in the foreground, applying the mode at each transition
+// and stepping plugins with a transition window once a minute while the
+// window is open. With mode: system it instead polls the OS appearance
+// every couple of seconds and re-applies as soon as it flips. The config
+// is reloaded every tick, and the current mode is re-applied as soon as the
+// config file changes, so edits take effect without a restart.
+//
+// A Unix socket next to the config lets light, dark, status, pause and
+// resume talk to the daemon, so it stays the single source of the mode,
+// and lets uninstall shut it down.
+//
+// SIGHUP reloads the config like an edit does. SIGTERM and SIGINT stop the
+// daemon once any plugins it is running have finished.
+func runDaemon(configPath string) {
+	if _, err := sendDaemon(configPath, "ping"); err == nil {
+		fmt.Fprintln(os.Stderr, "error: a daemon is already running")
+		os.Exit(1)
+	}
+
+	socketPath := internal.SocketPath(configPath)
+	// A daemon that was killed leaves its socket behind.
+	_ = os.Remove(socketPath)
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	requests := make(chan daemonRequest)
+	go serveDaemon(listener, requests)
+
+	// Editors often save by renaming a new file over the old one, which
+	// ends a watch on the file itself, so watch its directory.
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := watcher.Add(filepath.Dir(configPath)); err != nil {
+		fmt.Fprintf(os.Stderr, "error: watching config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// changed fires once a burst of writes to the config has settled.
+	var changed <-chan time.Time
+
+	// Signals are handled between plugin runs, never in the middle of one.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM, os.Interrupt)
+
+	d := &daemon{configPath: configPath, stepping: map[string]bool{}}
+	next := time.After(d.tick())
+	for {
+		select {
+		case <-next:
+		case req := <-requests:
+			req.reply <- d.handle(req.command)
+			if req.command == "shutdown" {
+				<-req.sent
+				fmt.Println("Shutting down")
+				listener.Close()
+				return
+			}
+		case <-changed:
+			changed = nil
+			fmt.Println("Config changed, re-applying")
+			d.reload()
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				fmt.Println("Reloading config")
+				d.reload()
+				break
+			}
+			fmt.Println("Shutting down")
+			listener.Close()
+			return
+		case event := <-watcher.Events:
+			// Other files in the directory, like the state file, are ignored.
+			if filepath.Base(event.Name) == filepath.Base(configPath) && event.Has(fsnotify.Write|fsnotify.Create) {
+				changed = time.After(300 * time.Millisecond)
+			}
+			continue
+		case err := <-watcher.Errors:
+			fmt.Fprintf(os.Stderr, "error: watching config: %v\n", err)
+			continue
+		}
+		next = time.After(d.tick())
+	}
+}
+
+// reload re-applies the current mode with the edited config. The tick
+// after it validates the config, so an invalid one is reported and nothing
+// is applied until it is fixed.
+func (d *daemon) reload() {
+	if d.paused {
+		return
+	}
+	if d.forced == "" {
+		d.applied = false
+		return
+	}
+
+	cfg, err := loadConfig(d.configPath)
+	if err != nil {
+		return
+	}
+	isLight := d.forced == "light"
+	applyMode(d.configPath, cfg, isLight, true, forcedMode(isLight))
+}
+
+// tick applies a transition if one happened and steps gradual transitions.
+// It returns how long to wait before the next tick.
+func (d *daemon) tick() time.Duration {
+	cfg, err := loadConfig(d.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return time.Minute
+	}
+
+	interval := time.Minute
+	if cfg.Mode == "system" {
+		interval = 2 * time.Second
+	}
+
+	loc, err := internal.LoadLocation(cfg.Location.Timezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return interval
+	}
+
+	now := time.Now().In(loc)
+	sunrise, sunset := cfg.Times(now)
+	isLight := now.After(sunrise) && now.Before(sunset)
+	inDusk := cfg.InDusk(now, sunrise, sunset)
+	modeFor := solarMode(now, sunrise, sunset, inDusk)
+
+	if cfg.Mode == "system" {
+		isLight, err = internal.SystemIsLight()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return interval
+		}
+		inDusk = false
+		modeFor = forcedMode(isLight)
+	}
+
+	if d.paused {
+		return interval
+	}
+
+	// A shared screen holds transitions and steps until sharing ends.
+	if cfg.PauseWhileSharing && screenShared(cfg) {
+		return interval
+	}
+
+	if !d.applied || isLight != d.lastLight || inDusk != d.lastDusk {
+		// A transition ends a forced mode.
+		d.forced = ""
+		applyMode(d.configPath, cfg, isLight, false, modeFor)
+		d.applied, d.lastLight, d.lastDusk = true, isLight, inDusk
+		return interval
+	}
+
+	if d.forced != "" || cfg.Mode == "system" {
+		return interval
+	}
+
+	for _, entry := range cfg.Plugins {
+		// A plugin that has just left its window takes one last step, to
+		// exactly its day or night level.
+		inWindow := entry.InTransition(now, sunrise, sunset)
+		if !entry.Enabled || entry.Mode != "" || !inWindow && !d.stepping[entry.Label] {
+			continue
+		}
+		if entry.SkipOnBattery && internal.OnBattery() {
+			continue
+		}
+
+		pluginFunc := plugins.Registry[entry.Name]
+		if !plugins.Detect(entry.Name, entry.PluginConfig) {
+			continue
+		}
+		d.stepping[entry.Label] = inWindow
+
+		start := time.Now()
+		config, err := prepare(cfg, entry, modeFor, now)
+		if err == nil {
+			err = pluginFunc(config)
+		}
+		output := plugins.TakeOutput()
+		if err != nil {
+			logPlugin(entry.Label, time.Since(start), output, err)
+			continue
+		}
+		logEvent(fmt.Sprintf("  ~ %s %d%%\n", entry.Label, int(config.Level*100)),
+			"stepped", "plugin", entry.Label, "level", config.Level, "duration", time.Since(start).Seconds())
+	}
+
+	return interval
+}
+
+// handle runs one control command and returns the reply for the client.
+// Failed commands reply with an "error: " prefix, and light or dark with
+// failed plugins with a "failed: " prefix.
+func (d *daemon) handle(command string) string {
+	switch command {
+	case "ping":
+		return "ok\n"
+	case "light", "dark":
+		cfg, err := loadConfig(d.configPath)
+		if err != nil {
+			return fmt.Sprintf("error: %v\n", err)
+		}
+		isLight := command == "light"
+		ok := applyMode(d.configPath, cfg, isLight, true, forcedMode(isLight))
+		d.forced = command
+		if !ok {
+			return fmt.Sprintf("failed: applied %s mode until the next transition, but some plugins failed; see the daemon's output\n", command)
+		}
+		return fmt.Sprintf("Applied %s mode until the next transition\n", command)
+	case "shutdown":
+		return "Stopped the running daemon\n"
+	case "pause":
+		d.paused = true
+		return "Paused automatic switching\n"
+	case "resume":
+		d.paused = false
+		// Re-apply whatever the current mode is now.
+		d.applied = false
+		return "Resumed automatic switching\n"
+	case "status":
+		mode := internal.T("dark")
+		if d.lastLight {
+			mode = internal.T("light")
+		}
+		if d.lastDusk {
+			mode = internal.T("dusk")
+		}
+		if d.forced != "" {
+			mode = fmt.Sprintf(internal.T("%s (forced until the next transition)"), internal.T(d.forced))
+		}
+		var b strings.Builder
+		fmt.Fprint(&b, internal.T("Daemon: running\n"))
+		fmt.Fprintf(&b, internal.T("  Applied mode: %s\n"), mode)
+		if d.paused {
+			fmt.Fprint(&b, internal.T("  Automatic switching: paused\n"))
+		}
+		return b.String()
+	}
+	return fmt.Sprintf("error: unknown daemon command: %s\n", command)
+}
+
+// serveDaemon accepts control connections, each carrying one command line,
+// and hands them to the daemon loop.
+func serveDaemon(listener net.Listener, requests chan<- daemonRequest) {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			continue
+		}
+		go func() {
+			defer conn.Close()
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil {
+				return
+			}
+			req := daemonRequest{command: strings.TrimSpace(line), reply: make(chan string), sent: make(chan struct{})}
+			requests <- req
+			io.WriteString(conn, <-req.reply)
+			close(req.sent)
+		}()
+	}
+}
+
+// sendDaemon sends one command to a running daemon and returns its reply.
+// It fails when no daemon is listening.
+func sendDaemon(configPath, command string) (string, error) {
+	conn, err := net.DialTimeout("unix", internal.SocketPath(configPath), time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return "", err
+	}
+	reply, err := io.ReadAll(conn)
+	return string(reply), err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brittonhayes/day-night-cycle/internal"
+)
+
+// pushFleet runs the fleet command with mode on every host at once, like
+// the remote plugin, and logs each like a plugin. Failures don't fail the
+// transition here. The hosts get --no-fleet, so one with the same config
+// doesn't push back.
+func pushFleet(fleet internal.FleetConfig, mode string) {
+	command := fleet.Command
+	if command == "" {
+		command = "day-night-cycle"
+	}
+
+	var wg sync.WaitGroup
+	for _, host := range fleet.Hosts {
+		wg.Go(func() {
+			start := time.Now()
+			cmd := exec.Command("ssh",
+				"-o", "BatchMode=yes",
+				"-o", "ConnectTimeout=10",
+				host, command+" --no-fleet "+mode)
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				err = fmt.Errorf("ssh %s failed: %w", host, err)
+			}
+			logPlugin("fleet "+host, time.Since(start), strings.TrimSpace(string(output)), err)
+		})
+	}
+	wg.Wait()
+}
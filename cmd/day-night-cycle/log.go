@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brittonhayes/day-night-cycle/internal"
+)
+
+// logFormat is "text" for the usual output or "json" for one structured
+// line per event, for log aggregators.
+var logFormat string
+
+// systemLog mirrors run output to the system log, from logs.system in the
+// config.
+var systemLog bool
+
+var jsonLog = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		switch a.Key {
+		case slog.TimeKey:
+			a.Key = "ts"
+		case slog.LevelKey:
+			a.Value = slog.StringValue(strings.ToLower(a.Value.String()))
+		}
+		return a
+	},
+}))
+
+// logEvent prints text as is, or in JSON mode an info line with msg and
+// attrs.
+func logEvent(text, msg string, attrs ...any) {
+	if systemLog {
+		toSystemLog(strings.TrimSpace(text), false)
+	}
+	if logFormat == "json" {
+		jsonLog.Info(msg, attrs...)
+		return
+	}
+	fmt.Print(text)
+}
+
+// logPlugin reports one plugin run, how long it took, in seconds in JSON
+// mode, and what its commands printed, so a failure can be diagnosed from
+// the logs after the fact.
+func logPlugin(label string, duration time.Duration, output string, err error) {
+	if systemLog {
+		message := fmt.Sprintf("%s applied in %s", label, duration.Round(time.Millisecond))
+		if err != nil {
+			message = fmt.Sprintf("%s failed after %s: %v", label, duration.Round(time.Millisecond), err)
+		}
+		if output != "" {
+			message += "\n" + output
+		}
+		toSystemLog(message, err != nil)
+	}
+	if logFormat == "json" {
+		attrs := []any{"plugin", label, "duration", duration.Seconds()}
+		if output != "" {
+			attrs = append(attrs, "output", output)
+		}
+		if err != nil {
+			jsonLog.Error("plugin failed", append(attrs, "error", err.Error())...)
+			return
+		}
+		jsonLog.Info("plugin applied", attrs...)
+		return
+	}
+	if err != nil {
+		fmt.Printf("  ✗ %s: %v (%s)\n", label, err, duration.Round(time.Millisecond))
+	} else {
+		fmt.Printf("  ✓ %s (%s)\n", label, duration.Round(time.Millisecond))
+	}
+	// Most errors already end with the output.
+	if output != "" && (err == nil || !strings.Contains(err.Error(), output)) {
+		for line := range strings.SplitSeq(output, "\n") {
+			fmt.Printf("      %s\n", line)
+		}
+	}
+}
+
+func toSystemLog(message string, failure bool) {
+	if err := internal.SystemLog(message, failure); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
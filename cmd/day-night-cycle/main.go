@@ -4,6 +4,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/brittonhayes/day-night-cycle/internal"
@@ -12,10 +19,49 @@ import (
 
 var Version = "dev"
 
+// profileName selects a named profile for this invocation.
+var profileName string
+
+// atomic switches a transition's plugins back when any plugin fails.
+var atomic bool
+
+// failFast stops a transition at the first plugin that fails.
+var failFast bool
+
+// noFleet keeps a transition on this machine, for runs pushed by a fleet
+// primary.
+var noFleet bool
+
+// Exit codes beyond 1, for any other error, tell launchd and scripts what
+// failed.
+const (
+	exitConfig  = 2 // The config can't be read or is invalid
+	exitPlugins = 3 // One or more plugins failed
+)
+
 func main() {
 	configPath := flag.String("config", internal.DefaultPath(), "path to config file")
+	flag.StringVar(&profileName, "profile", os.Getenv("DNC_PROFILE"), "named profile to use (default $DNC_PROFILE, then the last \"profile switch\")")
+	flag.BoolVar(&atomic, "atomic", false, "if any plugin fails, switch the others back so apps never end up half light, half dark")
+	flag.BoolVar(&failFast, "fail-fast", false, "stop a transition at the first plugin that fails")
+	flag.BoolVar(&noFleet, "no-fleet", false, "don't push the mode to the fleet hosts")
+	flag.StringVar(&plugins.HomeDir, "home-dir", os.Getenv("DNC_HOME"), "resolve the plugins' home-relative paths under this directory instead, to see what a run writes; state, backups, and reloads are skipped, but commands still reach running apps (default $DNC_HOME)")
+	flag.StringVar(&logFormat, "log-format", "text", "run output format: text, or json for one structured line per event")
 	flag.Usage = printUsage
 	flag.Parse()
+	if logFormat != "text" && logFormat != "json" {
+		fmt.Fprintf(os.Stderr, "error: invalid --log-format %q: want text or json\n", logFormat)
+		os.Exit(1)
+	}
+	plugins.BackupDir = internal.BackupDir(*configPath)
+	if err := plugins.LoadExternal(internal.PluginDir(*configPath)); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: external plugins: %v\n", err)
+	}
+	if trial() {
+		// A trial run stays on this machine and out of the real backups.
+		noFleet = true
+		plugins.BackupDir = ""
+	}
 
 	if flag.NArg() < 1 {
 		printUsage()
@@ -24,19 +70,131 @@ func main() {
 
 	command := flag.Arg(0)
 
+	// A running daemon owns the mode, so these go through it when it is up.
+	// The daemon runs with its own flags, so runs with other ones, or in a
+	// trial home, don't. status adds what the daemon is doing itself.
+	switch command {
+	case "light", "dark":
+		if trial() || atomic || failFast || profileName != "" {
+			break
+		}
+		fallthrough
+	case "pause", "resume":
+		if reply, err := sendDaemon(*configPath, command); err == nil {
+			if strings.HasPrefix(reply, "error: ") {
+				fmt.Fprint(os.Stderr, reply)
+				os.Exit(1)
+			}
+			if strings.HasPrefix(reply, "failed: ") {
+				fmt.Fprint(os.Stderr, reply)
+				os.Exit(exitPlugins)
+			}
+			fmt.Print(reply)
+			return
+		}
+	}
+
+	// launchd can miss a calendar run while the laptop sleeps, so commands
+	// that are run often apply a missed sunrise or sunset. They only query,
+	// so the run happens in the background and its log stays out of their
+	// output. A trial run has no state to tell what was missed, and a
+	// running daemon applies transitions itself.
+	switch command {
+	case "status", "statusbar", "next":
+		if _, err := sendDaemon(*configPath, "ping"); trial() || err == nil {
+			break
+		}
+		if kind, missed := missedTransition(*configPath); missed {
+			if command == "status" {
+				fmt.Fprintf(os.Stderr, internal.T("Catching up on missed %s in the background\n"), internal.T(kind))
+			}
+			catchUpInBackground(*configPath)
+		}
+	}
+
 	switch command {
 	case "auto":
-		runAuto(*configPath)
+		flags := flag.NewFlagSet("auto", flag.ExitOnError)
+		scheduled := flags.Bool("scheduled", false, "run as the schedule does: only apply a transition due since the last run, otherwise retry deferred plugins")
+		flags.Parse(flag.Args()[1:])
+		run := runAuto
+		if *scheduled {
+			run = runScheduled
+		}
+		if !run(*configPath) {
+			os.Exit(exitPlugins)
+		}
 	case "light":
-		runMode(*configPath, true)
+		if !runMode(*configPath, true) {
+			os.Exit(exitPlugins)
+		}
 	case "dark":
-		runMode(*configPath, false)
+		if !runMode(*configPath, false) {
+			os.Exit(exitPlugins)
+		}
+	case "toggle":
+		if !runToggle(*configPath) {
+			os.Exit(exitPlugins)
+		}
 	case "status":
 		runStatus(*configPath)
 	case "next":
-		runNext(*configPath)
+		runNext(*configPath, flag.Args()[1:])
+	case "moon":
+		runMoon(*configPath)
+	case "snooze":
+		runSnooze(*configPath, flag.Args()[1:])
+	case "sun":
+		runSun(*configPath, flag.Args()[1:])
+	case "preview":
+		runPreview(*configPath, flag.Args()[1:])
+	case "simulate":
+		runSimulate(*configPath, flag.Args()[1:])
 	case "schedule":
-		runSchedule(*configPath)
+		runSchedule(*configPath, flag.Args()[1:])
+	case "statusbar":
+		runStatusbar(*configPath)
+	case "profile":
+		runProfile(*configPath, flag.Args()[1:])
+	case "daemon":
+		runDaemon(*configPath)
+	case "plugin":
+		runPlugin(*configPath, flag.Args()[1:])
+	case "permissions":
+		runPermissions(*configPath)
+	case "healthcheck":
+		runHealthcheck(*configPath)
+	case "config":
+		runConfig(*configPath, flag.Args()[1:])
+	case "segment":
+		runSegment(*configPath, flag.Args()[1:])
+	case "shell-init":
+		runShellInit(*configPath, flag.Args()[1:])
+	case "shell-env":
+		runShellEnv(*configPath, flag.Args()[1:])
+	case "mode":
+		mode, err := currentMode(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Println(mode)
+	case "is-dark":
+		mode, err := currentMode(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		if mode != "dark" {
+			os.Exit(1)
+		}
+	case "pause", "resume":
+		fmt.Fprintf(os.Stderr, "error: %s needs a running daemon\n", command)
+		os.Exit(1)
+	case "self-update":
+		runSelfUpdate(*configPath)
+	case "uninstall":
+		runUninstall(*configPath, flag.Args()[1:])
 	case "version":
 		fmt.Printf("day-night-cycle version %s\n", Version)
 	default:
@@ -53,24 +211,82 @@ Usage:
   day-night-cycle [flags] <command>
 
 Commands:
-  auto      Apply mode based on current time
-  light     Force light mode
-  dark      Force dark mode
-  status    Show current status and schedule
-  next      Show next transition time
-  schedule  Generate launchd schedule
-  version   Show version
+  auto        Apply mode based on current time; --scheduled (used by the schedule) only when a transition is due, otherwise retrying deferred plugins
+  light       Force light mode
+  dark        Force dark mode
+  toggle      Switch to the opposite of the last applied mode
+  status      Show current status and schedule
+  next        Show next transition time; --format unix|seconds for scripts
+  snooze      Delay the next transition by a duration, e.g. "snooze 1h"; "snooze cancel" to undo
+  moon        Show the moon's phase, illumination, and moonrise/moonset
+  sun         Show today's sun times and position; --year charts sunrise/sunset with the offsets for the year
+  preview     Show sunrise/sunset, the mode at --time HH:MM, and the schedule for a date (YYYY-MM-DD)
+  simulate    Walk through a day (--date, default today) every --step (default 30m), showing the mode and which plugins switch
+  schedule    Generate launchd schedule; --format home-manager prints a Nix module instead; "schedule install|uninstall|status" to manage the agent
+  statusbar   Print SwiftBar/xbar menu bar output
+  profile     List profiles, or "profile switch [name]" to change and re-apply
+  daemon      Run in the foreground, stepping gradual transitions each minute
+  mode        Print the current mode: light or dark
+  is-dark     Exit 0 when the current mode is dark, 1 when light
+  config      "config export [--resolved] [file]", "config import <file>", "config migrate", "config schema", "config capture [file]", or "config sync"
+  segment     Print a prompt/status line segment; --style plain|tmux|starship|p10k
+  shell-init  Print a zsh, bash, or fish hook that exports DNC_MODE and the shell variables
+  shell-env   Print the exports for the current mode (used by the shell-init hook)
+  plugin      List the plugins for this system (--all for every one), "plugin info <name>" for one's options, "plugin themes <name>" for the themes installed for it, "plugin new <name>" to start one in a source checkout, or "plugin install [github.com/<owner>/<repo>[@version]]" to add an external one or reinstall the locked ones
+  permissions Ask for and check the macOS Automation permissions the enabled plugins need
+  healthcheck Exit 0 only if the schedule is installed and fresh and the last run succeeded
+  pause       Pause the running daemon's automatic switching
+  resume      Resume the running daemon's automatic switching
+  self-update Replace this binary with the latest release, verifying its checksum
+  uninstall   Stop a running daemon and remove the launchd agents, state, logs, and cache; --restore puts app settings back first
+  version     Show version
+
+Exit codes: 1 for errors, 2 when the config is invalid, 3 when a plugin failed.
 
 Flags:
 `)
 	flag.PrintDefaults()
 }
 
-func runAuto(configPath string) {
-	cfg, err := internal.Load(configPath)
+// runAuto applies the mode for the current time and reports whether every
+// plugin succeeded.
+func runAuto(configPath string) bool {
+	cfg, err := loadConfig(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitConfig)
+	}
+
+	// The launchd jobs run auto and schedule, so they rotate the logs.
+	if err := cfg.Logs.Rotate(internal.LogDir(configPath)); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	// The schedule retries, so the transition happens once sharing ends.
+	if cfg.PauseWhileSharing && screenShared(cfg) {
+		logEvent("Screen is shared; holding the transition until sharing ends\n", "held", "reason", "screen sharing")
+		if trial() {
+			return true
+		}
+		statePath := internal.StatePath(configPath)
+		state, err := internal.LoadState(statePath)
+		if err == nil {
+			state.Held = true
+			err = internal.SaveState(statePath, state)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		return true
+	}
+
+	if cfg.Mode == "system" {
+		isLight, err := internal.SystemIsLight()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return applyMode(configPath, cfg, isLight, false, forcedMode(isLight))
 	}
 
 	loc, err := internal.LoadLocation(cfg.Location.Timezone)
@@ -79,63 +295,500 @@ func runAuto(configPath string) {
 		os.Exit(1)
 	}
 
-	sunrise, sunset := internal.CalculateTimes(
-		cfg.Location.Latitude,
-		cfg.Location.Longitude,
-		time.Now().In(loc),
-	)
+	now := time.Now().In(loc)
+	sunrise, sunset := cfg.Times(now)
+	isLight := now.After(sunrise) && now.Before(sunset)
 
-	sunrise, sunset = cfg.Location.ApplyOffsets(sunrise, sunset)
+	return applyMode(configPath, cfg, isLight, false, settledMode(now, sunrise, sunset, cfg.InDusk(now, sunrise, sunset)))
+}
 
+// runScheduled is what the schedule runs, at load, at each scheduled time,
+// and every 15 minutes with retry. It applies the mode like auto when a
+// scheduled time has passed since the last run, except that a forced mode
+// lasts until the next sunrise or sunset. Otherwise it only retries what
+// is waiting: a transition held while the screen was shared, or the
+// deferred plugins, in the mode the others are in.
+func runScheduled(configPath string) bool {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	state, err := internal.LoadState(internal.StatePath(configPath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Mode == "system" || state.Applied.IsZero() || state.Held {
+		return runAuto(configPath)
+	}
+
+	loc, err := internal.LoadLocation(cfg.Location.Timezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// launchd runs at the start of a time's minute.
 	now := time.Now().In(loc)
-	isLight := now.After(sunrise) && now.Before(sunset)
+	due := func(t time.Time) bool {
+		t = t.Truncate(time.Minute)
+		return t.After(state.Applied) && !t.After(now)
+	}
+	for _, day := range scheduleTimes(cfg, now.AddDate(0, 0, -1), 2) {
+		times := []time.Time{day.Sunrise, day.Sunset}
+		if !state.Forced {
+			times = append(times, day.Plugins...)
+		}
+		if slices.ContainsFunc(times, due) {
+			return runAuto(configPath)
+		}
+	}
 
-	applyMode(cfg, isLight)
+	if len(state.Deferred) == 0 {
+		return true
+	}
+	var deferred []internal.ConfigPluginEntry
+	for _, pluginEntry := range cfg.Plugins {
+		if slices.Contains(state.Deferred, pluginEntry.Label) {
+			deferred = append(deferred, pluginEntry)
+		}
+	}
+	cfg.Plugins = deferred
+
+	isLight := state.Mode == "light"
+	if state.Forced {
+		return applyMode(configPath, cfg, isLight, true, forcedMode(isLight))
+	}
+	sunrise, sunset := cfg.Times(now)
+	return applyMode(configPath, cfg, isLight, false, settledMode(now, sunrise, sunset, cfg.InDusk(now, sunrise, sunset)))
 }
 
-func runMode(configPath string, isLight bool) {
-	cfg, err := internal.Load(configPath)
+func runMode(configPath string, isLight bool) bool {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+	return applyMode(configPath, cfg, isLight, true, forcedMode(isLight))
+}
+
+func runToggle(configPath string) bool {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	state, err := internal.LoadState(internal.StatePath(configPath))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
-	applyMode(cfg, isLight)
+
+	isLight := state.Mode == "dark"
+	return applyMode(configPath, cfg, isLight, true, forcedMode(isLight))
+}
+
+// modeFunc decides one plugin's mode: whether it is in day mode, its level
+// from night (0) to day (1) for plugins that transition gradually, and
+// whether its night mode is the dusk variant.
+type modeFunc func(entry internal.ConfigPluginEntry) (isLight bool, level float64, isDusk bool)
+
+// forcedMode puts every plugin in the same mode.
+func forcedMode(isLight bool) modeFunc {
+	level := 0.0
+	if isLight {
+		level = 1
+	}
+	return func(internal.ConfigPluginEntry) (bool, float64, bool) { return isLight, level, false }
+}
+
+// solarMode decides each plugin's mode from the sun, honoring its offsets
+// and transition window. inDusk reports whether the sun is in the dusk
+// band; plugins still in day mode by their own offsets ignore it.
+func solarMode(now, sunrise, sunset time.Time, inDusk bool) modeFunc {
+	return func(entry internal.ConfigPluginEntry) (bool, float64, bool) {
+		isLight := entry.IsLight(now, sunrise, sunset)
+		return isLight, entry.Level(now, sunrise, sunset), inDusk && !isLight
+	}
+}
+
+// settledMode is solarMode for a run that nothing steps afterwards: a
+// plugin inside its transition window goes straight to the level of the
+// mode it is switching to, not partway there.
+func settledMode(now, sunrise, sunset time.Time, inDusk bool) modeFunc {
+	modeFor := solarMode(now, sunrise, sunset, inDusk)
+	return func(entry internal.ConfigPluginEntry) (bool, float64, bool) {
+		isLight, _, isDusk := modeFor(entry)
+		if isLight {
+			return isLight, 1, isDusk
+		}
+		return isLight, 0, isDusk
+	}
+}
+
+// configure builds the runtime plugin config for an entry, with pinned
+// modes overriding modeFor.
+func configure(entry internal.ConfigPluginEntry, modeFor modeFunc) plugins.PluginConfig {
+	config := entry.PluginConfig
+	switch entry.Mode {
+	case "always-light":
+		config.IsLight, config.Level = true, 1
+	case "always-dark":
+		config.IsLight, config.Level = false, 0
+	default:
+		config.IsLight, config.Level, config.IsDusk = modeFor(entry)
+	}
+	if config.IsDusk && config.Dusk != "" {
+		config.Night = config.Dusk
+	}
+	return config
+}
+
+// prepare builds the config a plugin runs with at now: its mode, the sun's
+// elevation, the shared HTTP client, and resolved secrets.
+func prepare(cfg internal.Config, entry internal.ConfigPluginEntry, modeFor modeFunc, now time.Time) (plugins.PluginConfig, error) {
+	config := configure(entry, modeFor)
+	config.Elevation = cfg.Elevation(now)
+	config.MoonPhase = internal.MoonPhase(now)
+	config.HTTP = cfg.HTTPClient()
+
+	var err error
+	config.Custom, err = internal.ResolveSecrets(config.Custom)
+	return config, err
+}
+
+// trial reports whether this is a trial run under --home-dir, which
+// leaves the real state alone and reloads no apps.
+func trial() bool {
+	return plugins.HomeDir != ""
 }
 
-func applyMode(cfg internal.Config, isLight bool) {
+// applyMode runs every enabled plugin. isLight is the overall mode that is
+// reported and recorded, and forced records that it came from light, dark,
+// or toggle; modeFor decides each plugin's own mode, which can differ when
+// plugins have their own offsets. It reports whether every plugin
+// succeeded.
+func applyMode(configPath string, cfg internal.Config, isLight, forced bool, modeFor modeFunc) bool {
 	mode := "dark"
 	if isLight {
 		mode = "light"
 	}
-	fmt.Printf("\nApplying %s mode...\n", mode)
+	logEvent(fmt.Sprintf("\nApplying %s mode...\n", mode), "applying", "mode", mode)
 
 	success := 0
 	total := 0
+	var deferred, failed []string
+	var succeeded []internal.ConfigPluginEntry
+	// contrast is the Increase Contrast setting macos-system wrote, if any.
+	var contrast *bool
+	now := time.Now()
+	onBattery := sync.OnceValue(internal.OnBattery)
+
+	// previous is the mode an atomic run switches back to on failure.
+	var previous string
+	if atomic {
+		plugins.Record()
+		if state, err := internal.LoadState(internal.StatePath(configPath)); err == nil && !trial() {
+			previous = state.Mode
+		}
+	}
 
 	for _, pluginEntry := range cfg.Plugins {
 		if !pluginEntry.Enabled {
 			continue
 		}
 
-		pluginFunc, exists := plugins.Registry[pluginEntry.Name]
-		if !exists {
-			fmt.Printf("  ✗ %s: unknown plugin\n", pluginEntry.Name)
+		pluginFunc := plugins.Registry[pluginEntry.Name]
+		info, _ := plugins.Describe(pluginEntry.Name)
+
+		// Apps that aren't installed here are skipped, not failures, so one
+		// config can cover machines with different apps.
+		if !plugins.Detect(pluginEntry.Name, pluginEntry.PluginConfig) {
+			continue
+		}
+
+		requiresRunning := pluginEntry.RequiresRunning
+		if requiresRunning == "" {
+			requiresRunning = info.Running
+		}
+		if requiresRunning != "" && !processRunning(requiresRunning) {
+			continue
+		}
+
+		// A trial run only redirects files, so it leaves plugins that reach
+		// past them alone.
+		if info.System && trial() {
+			logEvent(fmt.Sprintf("  … %s: skipped: changes system settings\n", pluginEntry.Label),
+				"skipped", "plugin", pluginEntry.Label, "reason", "system settings")
+			continue
+		}
+
+		if pluginEntry.SkipOnBattery && onBattery() {
+			logEvent(fmt.Sprintf("  … %s: skipped on battery\n", pluginEntry.Label),
+				"skipped", "plugin", pluginEntry.Label, "reason", "battery")
+			continue
+		}
+
+		// Apps like Chromium rewrite their settings on exit, so changing them
+		// while running is lost or corrupts the file. The schedule retries.
+		requiresClosed := pluginEntry.RequiresClosed
+		if requiresClosed == "" {
+			requiresClosed = info.Closed
+		}
+		if requiresClosed != "" && processRunning(requiresClosed) {
+			logEvent(fmt.Sprintf("  … %s: deferred until %s quits\n", pluginEntry.Label, requiresClosed),
+				"deferred", "plugin", pluginEntry.Label, "until", requiresClosed)
+			deferred = append(deferred, pluginEntry.Label)
 			continue
 		}
 
 		total++
-		config := pluginEntry.PluginConfig
-		config.IsLight = isLight
-		err := pluginFunc(config)
+		config, err := prepare(cfg, pluginEntry, modeFor, now)
+
+		label := pluginEntry.Label
+		if config.IsLight && !isLight {
+			label += " (light)"
+		}
+		if !config.IsLight && isLight {
+			label += " (dark)"
+		}
+		if config.IsDusk {
+			label += " (dusk)"
+		}
+
+		start := time.Now()
+		if err == nil {
+			plugins.ResetChanged()
+			err = pluginFunc(config)
+		}
+		// Reloading an app with nothing new to read only interrupts it.
+		if err == nil && plugins.Changed() && !trial() {
+			err = pluginEntry.Reload.Run()
+		}
+		logPlugin(label, time.Since(start), plugins.TakeOutput(), err)
 		if err != nil {
-			fmt.Printf("  ✗ %s: %v\n", pluginEntry.Name, err)
+			failed = append(failed, pluginEntry.Label)
+			if failFast {
+				break
+			}
 		} else {
-			fmt.Printf("  ✓ %s\n", pluginEntry.Name)
 			success++
+			succeeded = append(succeeded, pluginEntry)
+			if on, ok := config.Custom["increase_contrast"].(bool); ok && pluginEntry.Name == "macos-system" {
+				contrast = &on
+			}
 		}
 	}
 
-	fmt.Printf("\nCompleted: %d/%d plugins successful\n", success, total)
+	logEvent(fmt.Sprintf("\nCompleted: %d/%d plugins successful\n", success, total),
+		"completed", "mode", mode, "succeeded", success, "total", total)
+
+	// Rolling back switches the plugins that succeeded back to the previous
+	// mode, reloads and all. Restoring their files is the fallback, for a
+	// first run or a plugin that can't switch back.
+	rolledBack := atomic && len(failed) > 0
+	if rolledBack {
+		var err error
+		if previous != "" && previous != mode {
+			logEvent(fmt.Sprintf("\nSwitching back to %s mode...\n", previous), "switching back", "mode", previous)
+			err = revert(cfg, succeeded, previous == "light", now)
+		}
+		if previous == "" || previous == mode || err != nil {
+			err = plugins.Rollback()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: rolling back: %v\n", err)
+		} else {
+			logEvent(fmt.Sprintf("Rolled back %s mode\n", mode), "rolled back", "mode", mode)
+		}
+	}
+
+	if trial() {
+		return len(failed) == 0
+	}
+	statePath := internal.StatePath(configPath)
+	state, err := internal.LoadState(statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return len(failed) == 0
+	}
+	if !rolledBack {
+		if cfg.Fleet.Primary && !noFleet && state.Mode != mode {
+			pushFleet(cfg.Fleet, mode)
+		}
+		state.Mode = mode
+		state.Forced = forced
+		state.Applied = time.Now()
+	}
+	// Increase Contrast doesn't follow the mode, so a rollback leaves it as
+	// written.
+	if contrast != nil {
+		state.IncreasedContrast = *contrast
+	}
+	state.Held = false
+	state.Deferred = deferred
+	state.Failed = failed
+	if err := internal.SaveState(statePath, state); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return len(failed) == 0
+}
+
+// revert runs entries again in the mode they were in before a failed
+// atomic run, reloading apps as applyMode does. It stops at the first
+// entry that fails.
+func revert(cfg internal.Config, entries []internal.ConfigPluginEntry, wasLight bool, now time.Time) error {
+	for _, entry := range entries {
+		start := time.Now()
+		config, err := prepare(cfg, entry, forcedMode(wasLight), now)
+		if err == nil {
+			plugins.ResetChanged()
+			err = plugins.Registry[entry.Name](config)
+		}
+		if err == nil && plugins.Changed() && !trial() {
+			err = entry.Reload.Run()
+		}
+		logPlugin(entry.Label, time.Since(start), plugins.TakeOutput(), err)
+		if err != nil {
+			return fmt.Errorf("switching %s back: %w", entry.Label, err)
+		}
+	}
+	return nil
+}
+
+// processRunning reports whether a process with exactly this name exists.
+func processRunning(name string) bool {
+	return exec.Command("pgrep", "-x", name).Run() == nil
+}
+
+// sharingProcesses run only while the screen is shared: Zoom's share host
+// and a macOS Screen Sharing session. Recording apps like OBS and the
+// screenshot toolbar run whether or not they are recording, so they
+// don't count, and neither do apps like Teams, Slack, and Discord or a
+// browser sharing a Meet tab, which show no process of their own.
+var sharingProcesses = []string{"CptHost", "screensharingd"}
+
+// screenShared reports whether the screen is being shared, by the built-in
+// processes or the config's sharingProcesses.
+func screenShared(cfg internal.Config) bool {
+	var names []string
+	for _, name := range slices.Concat(sharingProcesses, cfg.SharingProcesses) {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	return processRunning(strings.Join(names, "|"))
+}
+
+// missingProfile warns once per run about a switched-to profile that is
+// gone, however often the config is loaded.
+var missingProfile sync.Once
+
+// loadConfig loads the config and selects the active profile: --profile or
+// DNC_PROFILE first, then the one saved by "profile switch". A snoozed
+// transition is applied to the config's times, and Increase Contrast left
+// on by accessibility mode is turned off once the mode is.
+func loadConfig(configPath string) (internal.Config, error) {
+	cfg, err := internal.Load(configPath)
+	if err != nil {
+		return cfg, err
+	}
+	systemLog = cfg.Logs.System
+
+	state, err := internal.LoadState(internal.StatePath(configPath))
+	if err != nil {
+		return cfg, err
+	}
+	if !state.Snoozed.IsZero() {
+		cfg.Snooze(state.Snoozed, state.SnoozedUntil)
+	}
+
+	name := profileName
+	if name == "" {
+		name = state.Profile
+		// The profile may have been removed from the config since it was
+		// switched to. Only a name given for this run is an error.
+		if _, ok := cfg.Profiles[name]; name != "" && !ok {
+			missingProfile.Do(func() {
+				fmt.Fprintf(os.Stderr, "warning: profile %q from \"profile switch\" is no longer in the config; using the base config (\"profile switch\" to clear it)\n", name)
+			})
+			name = ""
+		}
+	}
+
+	if err := cfg.UseProfile(name); err != nil {
+		return cfg, err
+	}
+	if state.IncreasedContrast {
+		cfg.ResetContrast()
+	}
+	return cfg, nil
+}
+
+func runProfile(configPath string, args []string) {
+	cfg, err := internal.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	statePath := internal.StatePath(configPath)
+	state, err := internal.LoadState(statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) == 0 || args[0] == "list" {
+		active := profileName
+		if active == "" {
+			active = state.Profile
+		}
+		names := make([]string, 0, len(cfg.Profiles))
+		for name := range cfg.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		marker := func(name string) string {
+			if name == active {
+				return "*"
+			}
+			return " "
+		}
+		fmt.Printf("%s (default)\n", marker(""))
+		for _, name := range names {
+			fmt.Printf("%s %s\n", marker(name), name)
+		}
+		return
+	}
+
+	if args[0] != "switch" {
+		fmt.Fprintf(os.Stderr, "unknown profile command: %s\n", args[0])
+		os.Exit(1)
+	}
+
+	name := ""
+	if len(args) > 1 {
+		name = args[1]
+	}
+	if err := cfg.UseProfile(name); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	state.Profile = name
+	if err := internal.SaveState(statePath, state); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Re-apply the current mode under the new profile.
+	profileName = name
+	if !runAuto(configPath) {
+		os.Exit(exitPlugins)
+	}
 }
 
 func nextTransition(now, sunrise, sunset time.Time, loc internal.LocationConfig) (next time.Time, kind string) {
@@ -146,16 +799,66 @@ func nextTransition(now, sunrise, sunset time.Time, loc internal.LocationConfig)
 		return sunset, "sunset"
 	}
 	tomorrow := now.Add(24 * time.Hour)
-	next, _ = internal.CalculateTimes(loc.Latitude, loc.Longitude, tomorrow)
-	next, _ = loc.ApplyOffsets(next, time.Time{})
+	next, _ = loc.Times(tomorrow)
 	return next, "sunrise"
 }
 
+// lastTransition returns the most recent sunrise or sunset at or before now.
+func lastTransition(now, sunrise, sunset time.Time, loc internal.LocationConfig) (last time.Time, kind string) {
+	if !now.Before(sunset) {
+		return sunset, "sunset"
+	}
+	if !now.Before(sunrise) {
+		return sunrise, "sunrise"
+	}
+	yesterday := now.Add(-24 * time.Hour)
+	_, last = loc.Times(yesterday)
+	return last, "sunset"
+}
+
+// missedTransition reports whether a sunrise or sunset passed since the mode
+// was last applied. Errors count as nothing missed; the command itself
+// reports them.
+func missedTransition(configPath string) (kind string, missed bool) {
+	cfg, err := loadConfig(configPath)
+	if err != nil || cfg.Mode == "system" {
+		return "", false
+	}
+
+	loc, err := internal.LoadLocation(cfg.Location.Timezone)
+	if err != nil {
+		return "", false
+	}
+
+	state, err := internal.LoadState(internal.StatePath(configPath))
+	if err != nil || state.Applied.IsZero() {
+		return "", false
+	}
+
+	now := time.Now().In(loc)
+	sunrise, sunset := cfg.Times(now)
+	last, kind := lastTransition(now, sunrise, sunset, cfg.Location)
+	return kind, state.Applied.Before(last)
+}
+
+// catchUpInBackground starts "auto" detached from this process's output.
+func catchUpInBackground(configPath string) {
+	binaryPath, err := os.Executable()
+	if err != nil {
+		return
+	}
+	args := []string{"--config", configPath}
+	if profileName != "" {
+		args = append(args, "--profile", profileName)
+	}
+	_ = exec.Command(binaryPath, append(args, "auto")...).Start()
+}
+
 func runStatus(configPath string) {
-	cfg, err := internal.Load(configPath)
+	cfg, err := loadConfig(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitConfig)
 	}
 
 	loc, err := internal.LoadLocation(cfg.Location.Timezone)
@@ -165,53 +868,225 @@ func runStatus(configPath string) {
 	}
 
 	now := time.Now().In(loc)
-	sunrise, sunset := internal.CalculateTimes(
-		cfg.Location.Latitude,
-		cfg.Location.Longitude,
-		now,
-	)
+	sunrise, sunset := cfg.Times(now)
 
-	sunrise, sunset = cfg.Location.ApplyOffsets(sunrise, sunset)
-
-	isLight := now.After(sunrise) && now.Before(sunset)
-	currentMode := "dark"
-	if isLight {
-		currentMode = "light"
+	currentMode := sunMode(now, sunrise, sunset, cfg.InDusk(now, sunrise, sunset))
+	if cfg.Mode == "system" {
+		if systemLight, err := internal.SystemIsLight(); err == nil {
+			currentMode = "dark (following system)"
+			if systemLight {
+				currentMode = "light (following system)"
+			}
+		}
 	}
 
-	fmt.Printf("\nCurrent mode: %s\n", currentMode)
+	fmt.Printf(internal.T("\nCurrent mode: %s\n"), internal.T(currentMode))
+	if name := cfg.LocationName(); name != "" {
+		fmt.Printf(internal.T("Location: %s\n"), name)
+	}
+	if cfg.Location.Estimated() {
+		fmt.Printf(internal.T("Location: estimated from %s (%.2f, %.2f); times may be 15 minutes or more off, set latitude and longitude to fix\n"),
+			cfg.Location.Timezone, cfg.Location.Latitude, cfg.Location.Longitude)
+	}
 
 	if cfg.Location.DayOffset != "" {
-		fmt.Printf("Sunrise: %s (offset: %s)\n", sunrise.Format("3:04 PM"), cfg.Location.DayOffset)
+		fmt.Printf(internal.T("Sunrise: %s (offset: %s)\n"), internal.FormatTime(sunrise, "3:04 PM"), cfg.Location.DayOffset)
 	} else {
-		fmt.Printf("Sunrise: %s\n", sunrise.Format("3:04 PM"))
+		fmt.Printf(internal.T("Sunrise: %s\n"), internal.FormatTime(sunrise, "3:04 PM"))
 	}
 
 	if cfg.Location.NightOffset != "" {
-		fmt.Printf("Sunset: %s (offset: %s)\n", sunset.Format("3:04 PM"), cfg.Location.NightOffset)
+		fmt.Printf(internal.T("Sunset: %s (offset: %s)\n"), internal.FormatTime(sunset, "3:04 PM"), cfg.Location.NightOffset)
 	} else {
-		fmt.Printf("Sunset: %s\n", sunset.Format("3:04 PM"))
+		fmt.Printf(internal.T("Sunset: %s\n"), internal.FormatTime(sunset, "3:04 PM"))
+	}
+
+	if dawn, dusk := cfg.Twilight(now); !dawn.IsZero() {
+		fmt.Printf(internal.T("Dawn: %s, Dusk: %s\n"), internal.FormatTime(dawn, "3:04 PM"), internal.FormatTime(dusk, "3:04 PM"))
 	}
 
 	next, kind := nextTransition(now, sunrise, sunset, cfg.Location)
-	fmt.Printf("Next transition: %s (%s)\n", next.Format("3:04 PM"), kind)
+	fmt.Printf(internal.T("Next transition: %s (%s in %s)\n"), internal.FormatTime(next, "3:04 PM"), internal.T(kind), formatCountdown(next.Sub(now)))
+	fmt.Printf(internal.T("Sun elevation: %.1f°\n"), cfg.Elevation(now))
+	fmt.Printf(internal.T("Solar noon: %s\n"), internal.FormatTime(cfg.SolarNoon(now), "3:04 PM"))
 
-	fmt.Println("\nConfigured plugins:")
+	dayLength := cfg.DayLength(now)
+	change := dayLength - cfg.DayLength(now.AddDate(0, 0, -1))
+	comparison := "Day length: %s (%s longer than yesterday)\n"
+	if change < 0 {
+		change, comparison = -change, "Day length: %s (%s shorter than yesterday)\n"
+	}
+	fmt.Printf(internal.T(comparison), formatCountdown(dayLength), change.Round(time.Second))
+
+	season, name := internal.NextSeason(now)
+	season = season.In(loc)
+	fmt.Printf(internal.T("Next %s: %s (in %d days)\n"), internal.T(name), internal.FormatTime(season, "Mon Jan 2"), int(season.Sub(now).Hours()/24))
+	seasonLength := formatCountdown(cfg.DayLength(season))
+	switch {
+	case strings.HasSuffix(name, "equinox"):
+		fmt.Printf(internal.T("  Day and night are about equal: %s of daylight\n"), seasonLength)
+	case (name == "June solstice") == (cfg.Location.Latitude >= 0):
+		fmt.Printf(internal.T("  Longest day of the year here: %s\n"), seasonLength)
+	default:
+		fmt.Printf(internal.T("  Shortest day of the year here: %s\n"), seasonLength)
+	}
+
+	if state, err := internal.LoadState(internal.StatePath(configPath)); err == nil {
+		if len(state.Deferred) > 0 {
+			fmt.Printf(internal.T("Deferred: %s\n"), strings.Join(state.Deferred, ", "))
+		}
+		if cfg.PauseWhileSharing && screenShared(cfg) {
+			fmt.Println(internal.T("Held: the screen is shared, so transitions wait until sharing ends"))
+		}
+		if state.SnoozedUntil.After(now) {
+			fmt.Printf(internal.T("Snoozed: the %s transition until %s\n"), internal.FormatTime(state.Snoozed, "3:04 PM"), internal.FormatTime(state.SnoozedUntil, "3:04 PM"))
+		}
+	}
+
+	fmt.Println(internal.T("\nConfigured plugins:"))
 	for _, pluginEntry := range cfg.Plugins {
-		if pluginEntry.Enabled {
-			fmt.Printf("  • %s\n", pluginEntry.Name)
+		if !pluginEntry.Enabled {
+			continue
+		}
+		if info, _ := plugins.Describe(pluginEntry.Name); !info.Supported() {
+			fmt.Printf(internal.T("  ◦ %s (%s only, skipped)\n"), pluginEntry.Label, strings.Join(info.OS, ", "))
+			continue
+		}
+		if !plugins.Detect(pluginEntry.Name, pluginEntry.PluginConfig) {
+			fmt.Printf(internal.T("  ◦ %s (not detected, skipped)\n"), pluginEntry.Label)
+			continue
+		}
+		if pluginEntry.Mode != "" {
+			fmt.Printf("  • %s (%s)\n", pluginEntry.Label, pluginEntry.Mode)
+			continue
 		}
+		if pluginEntry.DayOffset == "" && pluginEntry.NightOffset == "" {
+			fmt.Printf("  • %s\n", pluginEntry.Label)
+			continue
+		}
+		pluginSunrise, pluginSunset := pluginEntry.ApplyOffsets(sunrise, sunset)
+		fmt.Printf(internal.T("  • %s (day %s, night %s)\n"), pluginEntry.Label,
+			internal.FormatTime(pluginSunrise, "3:04 PM"), internal.FormatTime(pluginSunset, "3:04 PM"))
 	}
 	fmt.Println()
+
+	if reply, err := sendDaemon(configPath, "status"); err == nil {
+		fmt.Println(reply)
+	}
 }
 
-func runNext(configPath string) {
-	cfg, err := internal.Load(configPath)
+// runNext prints the next transition. --format unix prints it as an epoch
+// timestamp and --format seconds as seconds from now, for scripts and
+// sleep loops.
+func runNext(configPath string, args []string) {
+	flags := flag.NewFlagSet("next", flag.ExitOnError)
+	format := flags.String("format", "text", "output format: text, unix, or seconds")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	loc, err := internal.LoadLocation(cfg.Location.Timezone)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
+	now := time.Now().In(loc)
+	sunrise, sunset := cfg.Times(now)
+
+	next, kind := nextTransition(now, sunrise, sunset, cfg.Location)
+	switch *format {
+	case "text":
+		fmt.Printf(internal.T("Next transition: %s (%s in %s)\n"), internal.FormatTime(next, "3:04 PM"), internal.T(kind), formatCountdown(next.Sub(now)))
+	case "unix":
+		fmt.Println(next.Unix())
+	case "seconds":
+		fmt.Println(int(next.Sub(now).Seconds()))
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown format: %s\n", *format)
+		os.Exit(1)
+	}
+}
+
+// runSnooze delays the next transition by a duration without touching the
+// config offsets, e.g. "snooze 1h" when sunset lands mid-session. Snoozing
+// again pushes the same transition further, and "snooze cancel" puts it
+// back. A loaded schedule is regenerated so launchd runs at the new time.
+func runSnooze(configPath string, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: day-night-cycle snooze <duration|cancel>")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	statePath := internal.StatePath(configPath)
+	state, err := internal.LoadState(statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args[0] == "cancel" {
+		state.Snoozed, state.SnoozedUntil = time.Time{}, time.Time{}
+		if err := internal.SaveState(statePath, state); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Snooze cancelled")
+	} else {
+		d, err := time.ParseDuration(args[0])
+		if err != nil || d <= 0 {
+			fmt.Fprintf(os.Stderr, "error: invalid duration %q, e.g. 30m or 1h\n", args[0])
+			os.Exit(1)
+		}
+		if cfg.Mode == "system" {
+			fmt.Fprintln(os.Stderr, "error: nothing to snooze with mode: system")
+			os.Exit(1)
+		}
+
+		loc, err := internal.LoadLocation(cfg.Location.Timezone)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+
+		now := time.Now().In(loc)
+		sunrise, sunset := cfg.Times(now)
+		next, kind := nextTransition(now, sunrise, sunset, cfg.Location)
+
+		// An already snoozed transition keeps its original time.
+		if !next.Equal(state.SnoozedUntil) {
+			state.Snoozed = next
+		}
+		state.SnoozedUntil = next.Add(d)
+		if err := internal.SaveState(statePath, state); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Snoozed %s until %s\n", kind, state.SnoozedUntil.Format("3:04 PM"))
+	}
+
+	if internal.AgentLoaded(internal.ScheduleAgent) {
+		generateSchedule(configPath)
+	}
+}
+
+func runMoon(configPath string) {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
 	loc, err := internal.LoadLocation(cfg.Location.Timezone)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -219,42 +1094,360 @@ func runNext(configPath string) {
 	}
 
 	now := time.Now().In(loc)
-	sunrise, sunset := internal.CalculateTimes(
-		cfg.Location.Latitude,
-		cfg.Location.Longitude,
-		now,
-	)
+	phase := internal.MoonPhase(now)
+	fmt.Printf("Phase: %s (%.0f%% illuminated)\n", internal.MoonPhaseName(phase), internal.MoonIllumination(now)*100)
 
-	sunrise, sunset = cfg.Location.ApplyOffsets(sunrise, sunset)
+	rise, set := cfg.MoonTimes(now)
+	fmt.Printf("Moonrise: %s\n", clockOrNone(rise))
+	fmt.Printf("Moonset: %s\n", clockOrNone(set))
+}
 
-	next, kind := nextTransition(now, sunrise, sunset, cfg.Location)
-	fmt.Printf("Next transition: %s (%s)\n", next.Format("3:04 PM"), kind)
+// clockOrNone formats a time of day, or "none today" for the zero time.
+func clockOrNone(t time.Time) string {
+	if t.IsZero() {
+		return "none today"
+	}
+	return t.Format("3:04 PM")
 }
 
-func runSchedule(configPath string) {
-	cfg, err := internal.Load(configPath)
+func runSchedule(configPath string, args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		flags := flag.NewFlagSet("schedule", flag.ExitOnError)
+		format := flags.String("format", "launchd", "launchd to write the agents, or home-manager to print a Nix module instead")
+		flags.Parse(args)
+		switch *format {
+		case "launchd":
+			generateSchedule(configPath)
+		case "home-manager":
+			printHomeManagerModule(configPath)
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown format: %s\n", *format)
+			os.Exit(1)
+		}
+		return
+	}
+
+	agents := []string{internal.ScheduleAgent, internal.RefreshAgent}
+
+	switch args[0] {
+	case "install":
+		generateSchedule(configPath)
+		for _, label := range agents {
+			if err := internal.LoadAgent(label); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Println("Automatic theme switching enabled")
+	case "uninstall":
+		for _, label := range agents {
+			if err := internal.UnloadAgent(label); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Println("Automatic theme switching disabled")
+	case "status":
+		for _, label := range agents {
+			status := "not installed"
+			if internal.AgentLoaded(label) {
+				status = "loaded"
+			} else if _, err := os.Stat(internal.AgentPath(label)); err == nil {
+				status = "installed, not loaded"
+			}
+			fmt.Printf("%s: %s\n", label, internal.T(status))
+		}
+		// Every run appends to the log, so its modification time is the
+		// last run.
+		if info, err := os.Stat(filepath.Join(internal.LogDir(configPath), "schedule.log")); err == nil {
+			fmt.Printf(internal.T("Last run: %s\n"), internal.FormatTime(info.ModTime(), "Mon Jan 2 3:04 PM"))
+		} else {
+			fmt.Println(internal.T("Last run: never"))
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown schedule command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// scheduleDays is how many days of transitions the launchd schedule holds,
+// so it stays accurate if the daily refresh misses a day.
+const scheduleDays = 7
+
+func generateSchedule(configPath string) {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	if err := cfg.Logs.Rotate(internal.LogDir(configPath)); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	loc, err := internal.LoadLocation(cfg.Location.Timezone)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if err := internal.Generate(configPath, scheduleTimes(cfg, time.Now().In(loc), scheduleDays), scheduleRetry(cfg)); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printHomeManagerModule prints today's schedule as a home-manager module.
+func printHomeManagerModule(configPath string) {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
 	loc, err := internal.LoadLocation(cfg.Location.Timezone)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
+	module, err := internal.HomeManagerModule(configPath, scheduleTimes(cfg, time.Now().In(loc), 1)[0], scheduleRetry(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(module)
+}
+
+// scheduleTimes returns the transition times of n days from now.
+func scheduleTimes(cfg internal.Config, now time.Time, n int) []internal.ScheduleDay {
+	var days []internal.ScheduleDay
+	for i := 0; i < n; i++ {
+		t := now.AddDate(0, 0, i)
+		day := internal.ScheduleDay{}
+		day.Sunrise, day.Sunset = cfg.Times(t)
+
+		for _, pluginEntry := range cfg.Plugins {
+			if !pluginEntry.Enabled || pluginEntry.Mode != "" {
+				continue
+			}
+			pluginSunrise, pluginSunset := pluginEntry.ApplyOffsets(day.Sunrise, day.Sunset)
+			day.Plugins = append(day.Plugins, pluginSunrise, pluginSunset)
+		}
+
+		if dawn, dusk := cfg.Twilight(t); !dawn.IsZero() {
+			day.Plugins = append(day.Plugins, dawn, dusk)
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// scheduleRetry reports whether the schedule should also run every 15
+// minutes: deferred plugins need another run after their app quits, and a
+// held transition after sharing ends.
+func scheduleRetry(cfg internal.Config) bool {
+	retry := cfg.PauseWhileSharing
+	for _, pluginEntry := range cfg.Plugins {
+		info, _ := plugins.Describe(pluginEntry.Name)
+		if pluginEntry.Enabled && (pluginEntry.RequiresClosed != "" || info.Closed != "") {
+			retry = true
+		}
+	}
+	return retry
+}
+
+// currentMode returns "light" or "dark": the last applied mode while it is
+// still in effect, so a forced mode counts, otherwise the mode computed
+// from the sun or the OS appearance.
+func currentMode(configPath string) (string, error) {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return "", err
+	}
+
+	var isLight bool
+	if cfg.Mode == "system" {
+		isLight, err = internal.SystemIsLight()
+		if err != nil {
+			return "", err
+		}
+	} else {
+		loc, err := internal.LoadLocation(cfg.Location.Timezone)
+		if err != nil {
+			return "", err
+		}
+		now := time.Now().In(loc)
+		sunrise, sunset := cfg.Times(now)
+		isLight = now.After(sunrise) && now.Before(sunset)
+
+		state, err := internal.LoadState(internal.StatePath(configPath))
+		if err != nil {
+			return "", err
+		}
+		if _, missed := missedTransition(configPath); state.Mode != "" && !missed {
+			return state.Mode, nil
+		}
+	}
+
+	if isLight {
+		return "light", nil
+	}
+	return "dark", nil
+}
+
+// runHealthcheck checks that the launchd schedule is loaded and was
+// refreshed recently, that no transition was missed, and that every plugin
+// succeeded in the last run. It exits 1 if any check fails, for monitoring
+// tools and watchdogs.
+func runHealthcheck(configPath string) {
+	healthy := true
+	check := func(ok bool, name, problem string) {
+		if ok {
+			fmt.Printf("ok    %s\n", name)
+			return
+		}
+		fmt.Printf("FAIL  %s: %s\n", name, problem)
+		healthy = false
+	}
+
+	check(internal.AgentLoaded(internal.ScheduleAgent), "schedule loaded", "run \"schedule install\"")
+
+	// The refresh job rewrites the plist daily.
+	info, err := os.Stat(internal.AgentPath(internal.ScheduleAgent))
+	check(err == nil && time.Since(info.ModTime()) < 48*time.Hour, "schedule fresh", "not regenerated in the last two days")
+
+	state, err := internal.LoadState(internal.StatePath(configPath))
+	check(err == nil && !state.Applied.IsZero(), "has run", "no run recorded")
+
+	kind, missed := missedTransition(configPath)
+	check(!missed, "no missed transitions", "the last "+kind+" was not applied")
+	check(len(state.Failed) == 0, "last run succeeded", "failed: "+strings.Join(state.Failed, ", "))
+
+	if !healthy {
+		os.Exit(1)
+	}
+}
+
+// runStatusbar prints output in the SwiftBar/xbar plugin format: the first
+// line is the menu bar title, lines after "---" form the dropdown menu.
+func runStatusbar(configPath string) {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Println("⚠️")
+		fmt.Println("---")
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+
+	loc, err := internal.LoadLocation(cfg.Location.Timezone)
+	if err != nil {
+		fmt.Println("⚠️")
+		fmt.Println("---")
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+
 	now := time.Now().In(loc)
-	sunrise, sunset := internal.CalculateTimes(
-		cfg.Location.Latitude,
-		cfg.Location.Longitude,
-		now,
-	)
+	sunrise, sunset := cfg.Times(now)
+
+	// A forced mode stays in effect until the next transition; otherwise
+	// show what mode and shell-env report.
+	var mode string
+	if state, err := internal.LoadState(internal.StatePath(configPath)); err == nil && state.Forced {
+		mode = state.Mode
+	} else if mode, err = currentMode(configPath); err != nil {
+		fmt.Println("⚠️")
+		fmt.Println("---")
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+
+	icon := "🌙"
+	if mode == "light" {
+		icon = "☀️"
+	}
 
-	sunrise, sunset = cfg.Location.ApplyOffsets(sunrise, sunset)
+	next, kind := nextTransition(now, sunrise, sunset, cfg.Location)
+	fmt.Printf("%s %s\n", icon, formatCountdown(next.Sub(now)))
+	fmt.Println("---")
+	fmt.Printf("Current mode: %s\n", mode)
+	fmt.Printf("Next %s: %s\n", kind, next.Format("3:04 PM"))
+	fmt.Println("---")
+
+	binaryPath, err := os.Executable()
+	if err != nil {
+		return
+	}
+	absConfigPath, err := filepath.Abs(configPath)
+	if err != nil {
+		absConfigPath = configPath
+	}
+	for _, action := range []string{"light", "dark", "toggle"} {
+		fmt.Printf("%s | bash=%q param1=--config param2=%q param3=%s terminal=false refresh=true\n",
+			strings.ToUpper(action[:1])+action[1:], binaryPath, absConfigPath, action)
+	}
+}
+
+// runSegment prints the mode icon and time to the next transition on one
+// line, colored for the target: tmux status lines, zsh prompt escapes for
+// Powerlevel10k, and plain text for starship, whose custom modules set the
+// style in starship.toml.
+func runSegment(configPath string, args []string) {
+	flags := flag.NewFlagSet("segment", flag.ExitOnError)
+	style := flags.String("style", "plain", "output style: plain, tmux, starship, or p10k")
+	flags.Parse(args)
 
-	if err := internal.Generate(configPath, sunrise, sunset); err != nil {
+	mode, err := currentMode(configPath)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	text, color := "🌙", "blue"
+	if mode == "light" {
+		text, color = "☀️", "yellow"
+	}
+
+	// The OS decides in system mode, so there is no transition to count to.
+	if cfg.Mode != "system" {
+		loc, err := internal.LoadLocation(cfg.Location.Timezone)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		now := time.Now().In(loc)
+		sunrise, sunset := cfg.Times(now)
+		next, _ := nextTransition(now, sunrise, sunset, cfg.Location)
+		text += " " + formatCountdown(next.Sub(now))
+	}
+
+	switch *style {
+	case "plain", "starship":
+		fmt.Println(text)
+	case "tmux":
+		fmt.Printf("#[fg=%s]%s#[default]\n", color, text)
+	case "p10k":
+		fmt.Printf("%%F{%s}%s%%f\n", color, text)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown style: %s\n", *style)
+		os.Exit(1)
+	}
+}
+
+// formatCountdown renders a duration as "2h 13m", or "13m" under an hour.
+func formatCountdown(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %dm", h, m)
 }
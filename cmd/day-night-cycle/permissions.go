@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/brittonhayes/day-night-cycle/plugins"
+)
+
+// runPermissions sends a harmless Apple event to every app the enabled
+// plugins script, so macOS asks for Automation permission now instead of
+// failing a scheduled run, and explains how to fix any that are denied.
+// None of the plugins script the UI, so Accessibility isn't needed. It
+// exits 1 if any app is denied.
+func runPermissions(configPath string) {
+	if runtime.GOOS != "darwin" {
+		fmt.Println("Automation permissions only apply on macOS")
+		return
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	var apps []string
+	for _, entry := range cfg.Plugins {
+		if !entry.Enabled || !plugins.Detect(entry.Name, entry.PluginConfig) {
+			continue
+		}
+		apps = append(apps, plugins.AutomationTargets(entry.Name)...)
+		if entry.Reload.Relaunch != "" {
+			apps = append(apps, entry.Reload.Relaunch)
+		}
+	}
+	slices.Sort(apps)
+	apps = slices.Compact(apps)
+
+	if len(apps) == 0 {
+		fmt.Println("No enabled plugin needs Automation permission")
+		return
+	}
+
+	fmt.Println("Automation (System Settings › Privacy & Security › Automation):")
+	denied := false
+	for _, app := range apps {
+		// Apps other than System Events aren't launched just to ask.
+		script := fmt.Sprintf(`tell application "%s" to count windows
+return "ok"`, app)
+		if app != "System Events" {
+			script = fmt.Sprintf(`if application "%s" is running then
+	tell application "%s" to count windows
+	return "ok"
+end if
+return "not running"`, app, app)
+		}
+
+		output, err := exec.Command("osascript", "-e", script).CombinedOutput()
+		result := strings.TrimSpace(string(output))
+		switch {
+		case err == nil && result == "not running":
+			fmt.Printf("  ◦ %s: not running; open it and run permissions again to check\n", app)
+		case err == nil:
+			fmt.Printf("  ✓ %s\n", app)
+		case strings.Contains(result, "-1743"):
+			denied = true
+			fmt.Printf("  ✗ %s: denied. Turn on %q under your terminal app, and under day-night-cycle for scheduled runs\n", app, app)
+		case strings.Contains(result, "-1712"):
+			denied = true
+			fmt.Printf("  ✗ %s: the permission prompt wasn't answered; run permissions again and click OK\n", app)
+		default:
+			// Any other error means the event was delivered, so it is allowed.
+			fmt.Printf("  ✓ %s\n", app)
+		}
+	}
+
+	fmt.Println("\nmacOS asks separately for scheduled runs, which are made by day-night-cycle itself rather than your terminal.")
+	if denied {
+		os.Exit(1)
+	}
+}
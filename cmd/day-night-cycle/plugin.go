@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/brittonhayes/day-night-cycle/internal"
+	"github.com/brittonhayes/day-night-cycle/plugins"
+)
+
+// runPlugin describes the built-in plugins: "plugin list" those that work
+// on this system, or every one with --all, and "plugin info <name>" one
+// plugin's custom options, and "plugin themes <name>" the themes installed
+// for it. "plugin new <name>" starts a new one in a
+// source checkout, and "plugin install" adds an external one.
+func runPlugin(configPath string, args []string) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("plugin list", flag.ExitOnError)
+		all := flags.Bool("all", false, "include plugins for other operating systems")
+		flags.Parse(args[1:])
+
+		for _, name := range slices.Sorted(maps.Keys(plugins.Registry)) {
+			info, _ := plugins.Describe(name)
+			if !*all && !info.Supported() {
+				continue
+			}
+			fmt.Printf("%-13s %-7s %s\n", name, osList(info), info.Description)
+		}
+	case "info":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "usage: day-night-cycle plugin info <name>")
+			os.Exit(1)
+		}
+		info, ok := plugins.Describe(args[1])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "error: unknown plugin %q\n", args[1])
+			os.Exit(1)
+		}
+
+		fmt.Printf("%s: %s\n", args[1], info.Description)
+		fmt.Printf("OS: %s\n", osList(info))
+		if info.Running != "" {
+			fmt.Printf("Skipped unless %s is running (override with requiresRunning)\n", info.Running)
+		}
+		if info.Closed != "" {
+			fmt.Printf("Deferred while %s is running (override with requiresClosed)\n", info.Closed)
+		}
+		if len(info.Keys) == 0 {
+			fmt.Println("No custom options")
+			return
+		}
+		fmt.Println("Custom options:")
+		for _, key := range info.Keys {
+			fmt.Printf("  %s (%s): %s\n", key.Name, key.Type, key.Description)
+		}
+	case "themes":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "usage: day-night-cycle plugin themes <name or label>")
+			os.Exit(1)
+		}
+		pluginThemes(configPath, args[1])
+	case "new":
+		pluginNew(args[1:])
+	case "install":
+		pluginInstall(configPath, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown plugin command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// pluginThemes prints the themes installed for a plugin, with light or dark
+// when known. A config entry with that label or name, when there is one,
+// says where to look, like a cursor entry pointed at VS Code.
+func pluginThemes(configPath, name string) {
+	entry := internal.ConfigPluginEntry{Name: name}
+	if cfg, err := loadConfig(configPath); err == nil {
+		i := slices.IndexFunc(cfg.Plugins, func(e internal.ConfigPluginEntry) bool { return e.Label == name })
+		if i == -1 {
+			i = slices.IndexFunc(cfg.Plugins, func(e internal.ConfigPluginEntry) bool { return e.Name == name })
+		}
+		if i != -1 {
+			entry = cfg.Plugins[i]
+		}
+	}
+	if _, ok := plugins.Describe(entry.Name); !ok {
+		fmt.Fprintf(os.Stderr, "error: unknown plugin %q\n", entry.Name)
+		os.Exit(1)
+	}
+
+	themes, ok, err := plugins.Themes(entry.Name, entry.PluginConfig)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: %s can't list its themes\n", entry.Name)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(themes) == 0 {
+		fmt.Fprintf(os.Stderr, "No %s themes found\n", entry.Name)
+		return
+	}
+	width := 0
+	for _, theme := range themes {
+		width = max(width, len(theme.Name))
+	}
+	for _, theme := range themes {
+		if theme.Mode == "" {
+			fmt.Println(theme.Name)
+			continue
+		}
+		fmt.Printf("%-*s  %s\n", width, theme.Name, theme.Mode)
+	}
+}
+
+func osList(info plugins.Info) string {
+	if len(info.OS) == 0 {
+		return "any"
+	}
+	return strings.Join(info.OS, ",")
+}
+
+// pluginNameRE matches plugin names like "claude-code".
+var pluginNameRE = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+var pluginTemplate = template.Must(template.New("plugin").Parse(`package plugins
+
+import "fmt"
+
+// {{.Func}} switches {{.Name}} between its Day and Night themes.
+func {{.Func}}(config PluginConfig) error {
+	theme := config.Night
+	if config.IsLight {
+		theme = config.Day
+	}
+	if theme == "" {
+		return nil
+	}
+
+	// Write theme to the app's settings with UpdateJSONTheme or
+	// UpdateINISettings, or switch it with run(exec.Command(...)) so the
+	// command's output is logged.
+	return fmt.Errorf("not implemented: set theme %q", theme)
+}
+
+// detect{{.Func}} checks for the app, so machines without it skip the
+// plugin.
+func detect{{.Func}}(config PluginConfig) bool {
+	return onPath("{{.Name}}")
+}
+`))
+
+var pluginTestTemplate = template.Must(template.New("plugin test").Parse(`package plugins
+
+import "testing"
+
+func Test{{.Func}}(t *testing.T) {
+	tests := []struct {
+		name    string
+		isLight bool
+		want    string
+	}{
+		{"day", true, "light-theme"},
+		{"night", false, "dark-theme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Paths from homeDir() land in a scratch directory.
+			HomeDir = t.TempDir()
+			t.Cleanup(func() { HomeDir = "" })
+
+			config := PluginConfig{Day: "light-theme", Night: "dark-theme", IsLight: tt.isLight}
+			if err := {{.Func}}(config); err != nil {
+				t.Fatal(err)
+			}
+			t.Errorf("TODO: check that the settings written under %s select %q", HomeDir, tt.want)
+		})
+	}
+}
+`))
+
+// pluginNew writes a skeleton plugin and its test into a source checkout,
+// registers it in the Registry, infos, and the config schema, and prints a
+// config entry to try it with. Nothing is written unless every edit works.
+func pluginNew(args []string) {
+	flags := flag.NewFlagSet("plugin new", flag.ExitOnError)
+	dir := flags.String("dir", ".", "root of the day-night-cycle source checkout")
+	goos := flags.String("os", "", "operating system the plugin works on: darwin or linux (default any)")
+	flags.Parse(args)
+	if flags.NArg() != 1 || !pluginNameRE.MatchString(flags.Arg(0)) {
+		fmt.Fprintln(os.Stderr, "usage: day-night-cycle plugin new [--os darwin|linux] [--dir path] <name>, with a name like my-app")
+		os.Exit(1)
+	}
+	name := flags.Arg(0)
+	if _, ok := plugins.Describe(name); ok {
+		fmt.Fprintf(os.Stderr, "error: plugin %q already exists\n", name)
+		os.Exit(1)
+	}
+
+	var fn strings.Builder
+	for part := range strings.SplitSeq(name, "-") {
+		fn.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	info := "Description: \"TODO: what it themes\",\n"
+	switch *goos {
+	case "":
+	case "darwin":
+		info += "OS: macOS,\n"
+	case "linux":
+		info += "OS: linux,\n"
+	default:
+		fmt.Fprintf(os.Stderr, "error: invalid --os %q: want darwin or linux\n", *goos)
+		os.Exit(1)
+	}
+
+	pluginDir := filepath.Join(*dir, "plugins")
+	base := filepath.Join(pluginDir, strings.ReplaceAll(name, "-", "_"))
+	path, testPath := base+".go", base+"_test.go"
+	if _, err := os.Stat(filepath.Join(pluginDir, "plugin.go")); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s is not a day-night-cycle checkout; run this from one or pass --dir\n", *dir)
+		os.Exit(1)
+	}
+	for _, p := range []string{path, testPath} {
+		if _, err := os.Stat(p); err == nil {
+			fmt.Fprintf(os.Stderr, "error: %s already exists\n", p)
+			os.Exit(1)
+		}
+	}
+
+	// Every file is edited in memory first, so a checkout that doesn't
+	// look as expected is left untouched.
+	data := struct{ Name, Func string }{name, fn.String()}
+	var src, testSrc bytes.Buffer
+	pluginTemplate.Execute(&src, data)
+	pluginTestTemplate.Execute(&testSrc, data)
+	files := map[string][]byte{path: src.Bytes(), testPath: testSrc.Bytes()}
+	steps := []struct {
+		path, after, insert string
+	}{
+		{filepath.Join(pluginDir, "plugin.go"), "var Registry = map[string]Plugin{",
+			fmt.Sprintf("%q: %s,\n", name, fn.String())},
+		{filepath.Join(pluginDir, "plugin.go"), "var detectors = map[string]Detector{",
+			fmt.Sprintf("%q: detect%s,\n", name, fn.String())},
+		{filepath.Join(pluginDir, "info.go"), "var infos = map[string]Info{",
+			fmt.Sprintf("%q: {\n%s},\n", name, info)},
+	}
+	for _, step := range steps {
+		src, ok := files[step.path]
+		if !ok {
+			var err error
+			if src, err = os.ReadFile(step.path); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		src, err := insertEntry(step.path, src, step.after, step.insert)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		files[step.path] = src
+	}
+	schemaPath := filepath.Join(*dir, "config.schema.json")
+	schema, err := os.ReadFile(schemaPath)
+	if err == nil {
+		files[schemaPath], err = addSchemaName(schemaPath, schema, name)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for path, src := range files {
+		if err := os.WriteFile(path, src, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Created %s and %s, and registered %s in plugins/plugin.go, plugins/info.go, and config.schema.json.\n", path, testPath, name)
+	fmt.Printf(`
+Next:
+  - Implement %s and detect%s, and finish its test
+  - Describe it in plugins/info.go, with any custom keys it reads
+  - Add it to the plugin lists in README.md and CLAUDE.md
+  - Build, and try both modes with this entry in a test config:
+
+plugins:
+  - name: %s
+    enabled: true
+    day: <light theme>
+    night: <dark theme>
+`, fn.String(), fn.String(), name)
+}
+
+// insertEntry adds an entry at the end of the map literal in the Go file
+// data that opens with the line after, and gofmts the result. path is for
+// errors.
+func insertEntry(path string, data []byte, after, entry string) ([]byte, error) {
+	src := string(data)
+	start := strings.Index(src, after)
+	if start < 0 {
+		return nil, fmt.Errorf("%s: %q not found", path, after)
+	}
+	n := strings.Index(src[start:], "\n}\n")
+	if n < 0 {
+		return nil, fmt.Errorf("%s: end of %q not found", path, after)
+	}
+	end := start + n + 1
+	formatted, err := format.Source([]byte(src[:end] + entry + src[end:]))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return formatted, nil
+}
+
+// addSchemaName adds name to the list of plugin names in the schema data.
+// path is for errors.
+func addSchemaName(path string, data []byte, name string) ([]byte, error) {
+	src := string(data)
+	start := strings.Index(src, `"description": "Plugin identifier"`)
+	if start < 0 {
+		return nil, fmt.Errorf("%s: plugin names not found", path)
+	}
+	// The new name goes after the last one, on its own line with the same
+	// indentation.
+	end := start + strings.Index(src[start:], "]")
+	closeQuote := strings.LastIndex(src[:end], `"`)
+	openQuote := strings.LastIndex(src[:closeQuote], `"`)
+	indent := src[strings.LastIndex(src[:openQuote], "\n"):openQuote]
+	src = src[:closeQuote+1] + "," + indent + strconv.Quote(name) + src[closeQuote+1:]
+	return []byte(src), nil
+}
+
+// pluginInstall downloads an external plugin from a GitHub release into
+// the plugins directory: the "<repo>-<os>-<arch>" binary, checked against
+// the release's checksums.txt like self-update. The plugin is named after
+// the repository without a "dnc-plugin-" prefix. Without @version the
+// latest release is installed; installing again updates it. With no
+// arguments every plugin in plugins.lock is installed again at its locked
+// version, e.g. on a new machine, and must match its locked checksum.
+func pluginInstall(configPath string, args []string) {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "usage: day-night-cycle plugin install [github.com/<owner>/<repo>[@version]]")
+		os.Exit(1)
+	}
+
+	dir := internal.PluginDir(configPath)
+	lock, err := plugins.ReadLock(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := http.DefaultClient
+	if cfg, err := loadConfig(configPath); err == nil {
+		client = cfg.HTTPClient()
+	}
+
+	if len(args) == 0 {
+		for _, name := range slices.Sorted(maps.Keys(lock)) {
+			locked := lock[name]
+			installed, err := installPlugin(client, dir, locked.Source+"@"+locked.Version, locked.SHA256)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			lock[name] = installed
+			fmt.Printf("Installed %s %s\n", name, locked.Version)
+		}
+		if err := plugins.WriteLock(dir, lock); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !strings.HasPrefix(args[0], "github.com/") || strings.Count(strings.Split(args[0], "@")[0], "/") != 2 {
+		fmt.Fprintf(os.Stderr, "error: invalid plugin %q: want github.com/<owner>/<repo>[@version]\n", args[0])
+		os.Exit(1)
+	}
+	name := pluginName(args[0])
+	if _, builtIn := plugins.Registry[name]; builtIn && lock[name].Source == "" {
+		fmt.Fprintf(os.Stderr, "error: %s is a built-in plugin\n", name)
+		os.Exit(1)
+	}
+
+	installed, err := installPlugin(client, dir, args[0], "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	lock[name] = installed
+	if err := plugins.WriteLock(dir, lock); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Installed %s %s; add it to the config with \"name: %s\"\n", name, installed.Version, name)
+}
+
+// pluginName names an external plugin after its repository, without a
+// "dnc-plugin-" prefix.
+func pluginName(source string) string {
+	source, _, _ = strings.Cut(source, "@")
+	return strings.TrimPrefix(path.Base(source), "dnc-plugin-")
+}
+
+// installPlugin downloads the release of source, "github.com/<owner>/<repo>"
+// with an optional "@version", into dir. A non-empty wantSum is the SHA-256
+// the binary must have.
+func installPlugin(client *http.Client, dir, source, wantSum string) (plugins.Installed, error) {
+	source, version, _ := strings.Cut(source, "@")
+	repo := strings.TrimPrefix(source, "github.com/")
+
+	url := "https://api.github.com/repos/" + repo + "/releases/latest"
+	if version != "" {
+		url = "https://api.github.com/repos/" + repo + "/releases/tags/" + version
+	}
+	var rel release
+	data, err := download(client, url)
+	if err == nil {
+		err = json.Unmarshal(data, &rel)
+	}
+	if err != nil {
+		return plugins.Installed{}, fmt.Errorf("finding the release of %s: %w", source, err)
+	}
+	binary, err := downloadBinary(client, rel, path.Base(repo))
+	if err != nil {
+		return plugins.Installed{}, err
+	}
+	sum := sha256.Sum256(binary)
+	gotSum := hex.EncodeToString(sum[:])
+	if wantSum != "" && gotSum != wantSum {
+		return plugins.Installed{}, fmt.Errorf("release %s changed since it was locked: SHA-256 %s, want %s", rel.TagName, gotSum, wantSum)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return plugins.Installed{}, err
+	}
+	// Rename into place so a run never executes a partial file.
+	exe := filepath.Join(dir, pluginName(source))
+	if err := os.WriteFile(exe+".new", binary, 0755); err != nil {
+		return plugins.Installed{}, err
+	}
+	if err := os.Rename(exe+".new", exe); err != nil {
+		os.Remove(exe + ".new")
+		return plugins.Installed{}, err
+	}
+	return plugins.Installed{Source: source, Version: rel.TagName, SHA256: gotSum}, nil
+}
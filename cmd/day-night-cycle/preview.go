@@ -0,0 +1,254 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/brittonhayes/day-night-cycle/internal"
+	"github.com/brittonhayes/day-night-cycle/plugins"
+)
+
+// runPreview shows what would happen on another date: sunrise and sunset
+// with the offsets, the mode each plugin would be in at --time, and the
+// times the schedule would run on that day. Nothing is applied.
+func runPreview(configPath string, args []string) {
+	flags := flag.NewFlagSet("preview", flag.ExitOnError)
+	clock := flags.String("time", "", "time of day to show the mode for, as HH:MM (default: now)")
+	flags.Parse(args)
+
+	// The date may come before or after --time.
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: day-night-cycle preview <YYYY-MM-DD> [--time HH:MM]")
+		os.Exit(1)
+	}
+	day := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+	if flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: day-night-cycle preview <YYYY-MM-DD> [--time HH:MM]")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	loc, err := internal.LoadLocation(cfg.Location.Timezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", day, loc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid date %q, want YYYY-MM-DD\n", day)
+		os.Exit(1)
+	}
+
+	now := time.Now().In(loc)
+	at := time.Date(date.Year(), date.Month(), date.Day(), now.Hour(), now.Minute(), 0, 0, loc)
+	if *clock != "" {
+		t, err := time.Parse("15:04", *clock)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid time %q, want HH:MM\n", *clock)
+			os.Exit(1)
+		}
+		at = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+	}
+
+	sunrise, sunset := cfg.Times(date)
+	fmt.Printf("\n%s\n", date.Format("Monday, January 2, 2006"))
+
+	if cfg.Location.DayOffset != "" {
+		fmt.Printf("Sunrise: %s (offset: %s)\n", sunrise.Format("3:04 PM"), cfg.Location.DayOffset)
+	} else {
+		fmt.Printf("Sunrise: %s\n", sunrise.Format("3:04 PM"))
+	}
+
+	if cfg.Location.NightOffset != "" {
+		fmt.Printf("Sunset: %s (offset: %s)\n", sunset.Format("3:04 PM"), cfg.Location.NightOffset)
+	} else {
+		fmt.Printf("Sunset: %s\n", sunset.Format("3:04 PM"))
+	}
+
+	dawn, dusk := cfg.Twilight(date)
+	if !dawn.IsZero() {
+		fmt.Printf("Dawn: %s, Dusk: %s\n", dawn.Format("3:04 PM"), dusk.Format("3:04 PM"))
+	}
+	fmt.Printf("Day length: %s\n", formatCountdown(cfg.DayLength(date)))
+
+	inDusk := cfg.InDusk(at, sunrise, sunset)
+	mode := sunMode(at, sunrise, sunset, inDusk)
+	if cfg.Mode == "system" {
+		mode = "whatever the system appearance is (mode: system)"
+	}
+	fmt.Printf("\nMode at %s: %s\n", at.Format("3:04 PM"), mode)
+
+	if cfg.Mode != "system" {
+		modeFor := solarMode(at, sunrise, sunset, inDusk)
+		for _, entry := range cfg.Plugins {
+			if !entry.Enabled {
+				continue
+			}
+			fmt.Printf("  • %s: %s\n", entry.Label, pluginMode(entry, configure(entry, modeFor)))
+		}
+	}
+
+	fmt.Println("\nScheduled runs:")
+	for _, r := range scheduledRuns(cfg, sunrise, sunset, dawn, dusk) {
+		fmt.Printf("  %8s  %s\n", r.at.Format("3:04 PM"), r.what)
+	}
+	fmt.Println()
+}
+
+// runSimulate walks through a whole day in steps, printing the mode at
+// each step and at each scheduled run, and under each line the plugins
+// whose mode changed since the one before, with the value they would
+// apply. Nothing is applied.
+func runSimulate(configPath string, args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	day := flags.String("date", "", "date to walk through, as YYYY-MM-DD (default: today)")
+	step := flags.Duration("step", 30*time.Minute, "time between steps")
+	flags.Parse(args)
+	if flags.NArg() != 0 || *step < time.Minute {
+		fmt.Fprintln(os.Stderr, "usage: day-night-cycle simulate [--date YYYY-MM-DD] [--step 30m]")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+	if cfg.Mode == "system" {
+		fmt.Fprintln(os.Stderr, "error: with mode: system the mode follows the system appearance, not the sun")
+		os.Exit(1)
+	}
+
+	loc, err := internal.LoadLocation(cfg.Location.Timezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	date := time.Now().In(loc)
+	if *day != "" {
+		date, err = time.ParseInLocation("2006-01-02", *day, loc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid date %q, want YYYY-MM-DD\n", *day)
+			os.Exit(1)
+		}
+	}
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 0, 1)
+
+	sunrise, sunset := cfg.Times(start)
+	dawn, dusk := cfg.Twilight(start)
+
+	var points []scheduledRun
+	for t := start; t.Before(end); t = t.Add(*step) {
+		points = append(points, scheduledRun{at: t})
+	}
+	for _, r := range scheduledRuns(cfg, sunrise, sunset, dawn, dusk) {
+		if !r.at.Before(start) && r.at.Before(end) {
+			points = append(points, r)
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].at.Before(points[j].at) })
+
+	fmt.Printf("\n%s, every %s\n\n", start.Format("Monday, January 2, 2006"), formatCountdown(*step))
+	last := map[string]string{}
+	for _, p := range points {
+		// A run shows the mode its transition switches to.
+		at := p.at
+		if p.what != "" {
+			at = at.Add(time.Second)
+		}
+		inDusk := cfg.InDusk(at, sunrise, sunset)
+		line := fmt.Sprintf("  %8s  %s", p.at.Format("3:04 PM"), sunMode(at, sunrise, sunset, inDusk))
+		if p.what != "" {
+			line += "  (" + p.what + " run)"
+		}
+		fmt.Println(line)
+
+		modeFor := solarMode(at, sunrise, sunset, inDusk)
+		for _, entry := range cfg.Plugins {
+			if !entry.Enabled {
+				continue
+			}
+			config := configure(entry, modeFor)
+			state := pluginMode(entry, config)
+			value := config.Night
+			if config.IsLight {
+				value = config.Day
+			}
+			if value != "" {
+				state += ": " + value
+			}
+			if last[entry.Label] == state {
+				continue
+			}
+			last[entry.Label] = state
+			fmt.Printf("              → %s %s\n", entry.Label, state)
+		}
+	}
+	fmt.Println()
+}
+
+// sunMode is the overall mode at a time: light between sunrise and sunset,
+// dusk in the dusk band, otherwise dark.
+func sunMode(at, sunrise, sunset time.Time, inDusk bool) string {
+	switch {
+	case inDusk:
+		return "dusk"
+	case at.After(sunrise) && at.Before(sunset):
+		return "light"
+	}
+	return "dark"
+}
+
+// pluginMode describes the mode configure gave an entry: light, dark,
+// dusk, or how far along a gradual transition it is, and its pin.
+func pluginMode(entry internal.ConfigPluginEntry, config plugins.PluginConfig) string {
+	mode := "dark"
+	switch {
+	case config.Level > 0 && config.Level < 1:
+		mode = fmt.Sprintf("%d%% of the way to light", int(config.Level*100))
+	case config.IsDusk:
+		mode = "dusk"
+	case config.IsLight:
+		mode = "light"
+	}
+	if entry.Mode != "" {
+		mode += " (" + entry.Mode + ")"
+	}
+	return mode
+}
+
+// scheduledRun is a time the schedule runs, and why.
+type scheduledRun struct {
+	at   time.Time
+	what string
+}
+
+// scheduledRuns returns the times generateSchedule writes for a day, in
+// order.
+func scheduledRuns(cfg internal.Config, sunrise, sunset, dawn, dusk time.Time) []scheduledRun {
+	runs := []scheduledRun{{sunrise, "sunrise"}, {sunset, "sunset"}}
+	for _, entry := range cfg.Plugins {
+		if !entry.Enabled || entry.Mode != "" || (entry.DayOffset == "" && entry.NightOffset == "") {
+			continue
+		}
+		pluginSunrise, pluginSunset := entry.ApplyOffsets(sunrise, sunset)
+		runs = append(runs, scheduledRun{pluginSunrise, entry.Label + " day"}, scheduledRun{pluginSunset, entry.Label + " night"})
+	}
+	if !dawn.IsZero() {
+		runs = append(runs, scheduledRun{dawn, "dawn"}, scheduledRun{dusk, "dusk"})
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].at.Before(runs[j].at) })
+	return runs
+}
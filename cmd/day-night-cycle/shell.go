@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runShellInit prints a hook for eval in the user's shell rc file. It
+// exports the environment for the current mode and refreshes it before
+// every prompt, so tools themed only through env vars follow along.
+func runShellInit(configPath string, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: day-night-cycle shell-init zsh|bash|fish")
+		os.Exit(1)
+	}
+	shell := args[0]
+
+	binaryPath, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	absConfigPath, err := filepath.Abs(configPath)
+	if err != nil {
+		absConfigPath = configPath
+	}
+	command := fmt.Sprintf("%s --config %s shell-env %s", quote(shell, binaryPath), quote(shell, absConfigPath), shell)
+
+	switch shell {
+	case "zsh":
+		fmt.Printf(`_dnc_refresh() { eval "$(%s)"; }
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd _dnc_refresh
+_dnc_refresh
+`, command)
+	case "bash":
+		fmt.Printf(`_dnc_refresh() { eval "$(%s)"; }
+PROMPT_COMMAND="_dnc_refresh${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+_dnc_refresh
+`, command)
+	case "fish":
+		fmt.Printf(`function _dnc_refresh --on-event fish_prompt
+    %s | source
+end
+_dnc_refresh
+`, command)
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported shell: %s (want zsh, bash, or fish)\n", shell)
+		os.Exit(1)
+	}
+}
+
+// runShellEnv prints export statements for DNC_MODE and the configured
+// shell variables of the current mode.
+func runShellEnv(configPath string, args []string) {
+	shell := "zsh"
+	if len(args) > 0 {
+		shell = args[0]
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	mode, err := currentMode(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	env := map[string]string{"DNC_MODE": mode}
+	vars := cfg.Shell.Night
+	if mode == "light" {
+		vars = cfg.Shell.Day
+	}
+	for name, value := range vars {
+		env[name] = value
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if shell == "fish" {
+			fmt.Printf("set -gx %s %s\n", name, quote(shell, env[name]))
+		} else {
+			fmt.Printf("export %s=%s\n", name, quote(shell, env[name]))
+		}
+	}
+}
+
+// quote single-quotes s for the given shell.
+func quote(shell, s string) string {
+	if shell == "fish" {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
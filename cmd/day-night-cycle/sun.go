@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brittonhayes/day-night-cycle/internal"
+)
+
+// chartCell is how much of the day each chart column covers.
+const chartCell = 30 * time.Minute
+
+// runSun prints today's sun times and position. With --year it charts
+// sunrise and sunset for every week of the year instead, marking where the
+// offsets keep the mode from following the sun.
+func runSun(configPath string, args []string) {
+	flags := flag.NewFlagSet("sun", flag.ExitOnError)
+	year := flags.Bool("year", false, "chart sunrise and sunset across the whole year")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	loc, err := internal.LoadLocation(cfg.Location.Timezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	now := time.Now().In(loc)
+	if *year {
+		printYearChart(cfg, now)
+		return
+	}
+
+	lat, lon := cfg.Location.Latitude, cfg.Location.Longitude
+	sunrise, sunset := internal.CalculateTimes(lat, lon, now)
+	modeSunrise, modeSunset := cfg.Times(now)
+	elevation, azimuth := internal.Position(lat, lon, now)
+	fmt.Printf("Sunrise: %s (light mode from %s)\n", sunrise.Format("3:04 PM"), modeSunrise.Format("3:04 PM"))
+	fmt.Printf("Solar noon: %s\n", cfg.SolarNoon(now).Format("3:04 PM"))
+	fmt.Printf("Sunset: %s (dark mode from %s)\n", sunset.Format("3:04 PM"), modeSunset.Format("3:04 PM"))
+	fmt.Printf("Sun: %.1f° elevation, %.0f° azimuth\n", elevation, azimuth)
+}
+
+// printYearChart draws one row per week of now's year, one column per half
+// hour: "#" while the sun is up and the mode is light, "." while it is down
+// and the mode is dark, and "~" in the offset bands where they disagree.
+// The times come from the built-in calculation, so a horizon profile or
+// provider is not reflected.
+func printYearChart(cfg internal.Config, now time.Time) {
+	lat, lon := cfg.Location.Latitude, cfg.Location.Longitude
+
+	// Colors only on a terminal, and never with NO_COLOR set.
+	color := map[byte]string{}
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == "" {
+		color = map[byte]string{'#': "\033[33m", '.': "\033[34m", '~': "\033[35m"}
+	}
+	paint := func(cells []byte) string {
+		var b strings.Builder
+		for _, c := range cells {
+			if code, ok := color[c]; ok {
+				b.WriteString(code)
+				b.WriteByte(c)
+				b.WriteString("\033[0m")
+				continue
+			}
+			b.WriteByte(c)
+		}
+		return b.String()
+	}
+
+	cells := int(24 * time.Hour / chartCell)
+	axis := []byte(strings.Repeat(" ", cells+2))
+	for hour := 0; hour <= 24; hour += 6 {
+		label := fmt.Sprint(hour)
+		copy(axis[hour*int(time.Hour/chartCell):], label)
+	}
+
+	fmt.Printf("\nDaylight in %d at %.2f, %.2f (%s)\n\n", now.Year(), lat, lon, now.Location())
+	fmt.Printf("        %s\n", strings.TrimRight(string(axis), " "))
+
+	day := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+	for ; day.Year() == now.Year(); day = day.AddDate(0, 0, 7) {
+		sunrise, sunset := internal.CalculateTimes(lat, lon, day)
+		modeSunrise, modeSunset := cfg.Location.ApplyOffsets(sunrise, sunset)
+
+		row := make([]byte, cells)
+		for i := range row {
+			t := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location()).
+				Add(time.Duration(i)*chartCell + chartCell/2)
+			sunUp := t.After(sunrise) && t.Before(sunset)
+			light := t.After(modeSunrise) && t.Before(modeSunset)
+			switch {
+			case sunUp != light:
+				row[i] = '~'
+			case sunUp:
+				row[i] = '#'
+			default:
+				row[i] = '.'
+			}
+		}
+		fmt.Printf("%s  %s  %8s – %s\n", day.Format("Jan _2"), paint(row),
+			modeSunrise.Format("3:04 PM"), modeSunset.Format("3:04 PM"))
+	}
+
+	fmt.Printf("\n%s sun up, light mode   %s sun down, dark mode   %s offset band\n",
+		paint([]byte("#")), paint([]byte(".")), paint([]byte("~")))
+	fmt.Println("Times are when the mode switches, with dayOffset and nightOffset applied.")
+	fmt.Println()
+}
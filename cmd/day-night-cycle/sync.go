@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/brittonhayes/day-night-cycle/internal"
+)
+
+// syncIgnore keeps per-machine files out of the synced repository.
+const syncIgnore = `state.json
+daemon.sock
+logs/
+backups/
+plugins/
+*.bak
+`
+
+// configSync shares the config directory through the git remote in
+// sync.remote: it commits local changes, rebases them onto the remote's,
+// and pushes. The first sync on a machine whose remote already has a config
+// takes that config, keeping the local one as config.yaml.bak. A conflict
+// or a merged config that doesn't load leaves the local config as it was.
+// Settings for one machine belong in its hosts/<hostname>.yaml overlay,
+// which no other machine touches.
+func configSync(configPath string) {
+	cfg, err := internal.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+	if cfg.Sync.Remote == "" {
+		fmt.Fprintln(os.Stderr, "error: set sync.remote to a git remote URL to sync the config")
+		os.Exit(exitConfig)
+	}
+	branch := cfg.Sync.Branch
+	if branch == "" {
+		branch = "main"
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	hostname, _ := os.Hostname()
+
+	_, err = os.Stat(filepath.Join(dir, ".git"))
+	joining := errors.Is(err, os.ErrNotExist)
+	if joining {
+		if _, err := git(dir, "init", "-b", branch); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(syncIgnore), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	remoteCommand := "set-url"
+	if _, err := git(dir, "remote", "get-url", "origin"); err != nil {
+		remoteCommand = "add"
+	}
+	if _, err := git(dir, "remote", remoteCommand, "origin", cfg.Sync.Remote); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// ls-remote exits 2 when the branch doesn't exist yet.
+	_, err = git(dir, "ls-remote", "--exit-code", "--heads", "origin", branch)
+	var exitErr *exec.ExitError
+	remoteExists := err == nil
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 2) {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if joining && remoteExists {
+		data, err := os.ReadFile(configPath)
+		if err == nil {
+			err = os.WriteFile(configPath+".bak", data, 0644)
+		}
+		if err == nil {
+			_, err = git(dir, "fetch", "origin", branch)
+		}
+		if err == nil {
+			_, err = git(dir, "checkout", "-f", "-B", branch, "origin/"+branch)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Took the config from %s; the previous one is %s.bak\n", cfg.Sync.Remote, filepath.Base(configPath))
+	}
+
+	if _, err := git(dir, "add", "-A"); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if changes, _ := git(dir, "status", "--porcelain"); changes != "" {
+		if _, err := git(dir, "commit", "-m", "Sync from "+hostname); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if remoteExists && !joining {
+		head, err := git(dir, "rev-parse", "HEAD")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := git(dir, "pull", "--rebase", "origin", branch); err != nil {
+			git(dir, "rebase", "--abort")
+			fmt.Fprintf(os.Stderr, "error: local and remote changes conflict, so the local config was kept; resolve them with git in %s, and keep settings for one machine in %s\n",
+				dir, internal.HostOverlayPath(configPath))
+			os.Exit(1)
+		}
+		if _, err := internal.Load(configPath); err != nil {
+			git(dir, "reset", "--hard", head)
+			fmt.Fprintf(os.Stderr, "error: the merged config is invalid, so the local config was kept: %v\n", err)
+			os.Exit(exitConfig)
+		}
+	}
+
+	if _, err := git(dir, "push", "-u", "origin", "HEAD:"+branch); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Synced %s with %s\n", dir, cfg.Sync.Remote)
+}
+
+// git runs a git command in dir and returns its trimmed output, with git's
+// own message in the error.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/brittonhayes/day-night-cycle/internal"
+	"github.com/brittonhayes/day-night-cycle/plugins"
+)
+
+// runUninstall removes everything the tool set up outside the config file:
+// the launchd agents, the state file, logs, the daemon socket, the
+// response cache, and installed external plugins. A running daemon is shut
+// down first, so it can't switch themes again. With --restore it then puts
+// back every app file as it was before the tool first changed it. The
+// config and binary are left for the user to delete.
+func runUninstall(configPath string, args []string) {
+	restore := len(args) > 0 && args[0] == "--restore"
+
+	for _, label := range []string{internal.ScheduleAgent, internal.RefreshAgent} {
+		if err := internal.UnloadAgent(label); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Println("Removed the launchd agents")
+
+	if reply, err := sendDaemon(configPath, "shutdown"); err == nil {
+		fmt.Print(reply)
+	}
+
+	if restore {
+		restored, err := plugins.RestoreBackups()
+		for _, path := range restored {
+			fmt.Printf("  ✓ restored %s\n", path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	paths := []string{
+		internal.StatePath(configPath),
+		internal.SocketPath(configPath),
+		internal.LogDir(configPath),
+		internal.CacheDir(),
+		internal.PluginDir(configPath),
+	}
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Println("Removed state, logs, cache, and installed plugins")
+
+	if _, err := os.Stat(plugins.BackupDir); err == nil && !restore {
+		fmt.Printf("App settings were left as they are; backups of the originals remain in %s\n", plugins.BackupDir)
+	}
+	fmt.Printf("To finish, delete %s and this binary\n", configPath)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const releasesURL = "https://api.github.com/repos/brittonhayes/day-night-cycle/releases/latest"
+
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// runSelfUpdate replaces the running binary with the latest release for
+// this platform after checking it against the release's checksums.txt.
+// The file is replaced in place, so the path in the launchd plist stays
+// valid.
+func runSelfUpdate(configPath string) {
+	// Use the configured proxy and CA when there is a config.
+	client := http.DefaultClient
+	if cfg, err := loadConfig(configPath); err == nil {
+		client = cfg.HTTPClient()
+	}
+
+	if err := selfUpdate(client); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func selfUpdate(client *http.Client) error {
+	var rel release
+	data, err := download(client, releasesURL)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &rel); err != nil {
+		return fmt.Errorf("parsing release: %w", err)
+	}
+
+	if rel.TagName == Version {
+		fmt.Printf("Already up to date (%s)\n", Version)
+		return nil
+	}
+
+	binary, err := downloadBinary(client, rel, "day-night-cycle")
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("getting executable path: %w", err)
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return fmt.Errorf("resolving symlinks: %w", err)
+	}
+
+	// Write next to the binary and rename over it, so the swap is atomic
+	// and a running schedule never sees a partial file.
+	tmp := exe + ".new"
+	if err := os.WriteFile(tmp, binary, 0755); err != nil {
+		return fmt.Errorf("writing update (try sudo): %w", err)
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replacing %s (try sudo): %w", exe, err)
+	}
+
+	fmt.Printf("Updated %s from %s to %s\n", exe, Version, rel.TagName)
+	return nil
+}
+
+// downloadBinary downloads the release's "<name>-<os>-<arch>" binary for
+// this platform and checks it against the release's checksums.txt.
+func downloadBinary(client *http.Client, rel release, name string) ([]byte, error) {
+	name = fmt.Sprintf("%s-%s-%s", name, runtime.GOOS, runtime.GOARCH)
+	var binaryURL, checksumsURL string
+	for _, asset := range rel.Assets {
+		switch asset.Name {
+		case name:
+			binaryURL = asset.URL
+		case "checksums.txt":
+			checksumsURL = asset.URL
+		}
+	}
+	if binaryURL == "" {
+		return nil, fmt.Errorf("release %s has no binary for %s/%s", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	if checksumsURL == "" {
+		return nil, fmt.Errorf("release %s has no checksums.txt to verify against", rel.TagName)
+	}
+
+	checksums, err := download(client, checksumsURL)
+	if err != nil {
+		return nil, err
+	}
+	want := checksumFor(checksums, name)
+	if want == "" {
+		return nil, fmt.Errorf("checksums.txt has no entry for %s", name)
+	}
+
+	fmt.Printf("Downloading %s %s...\n", name, rel.TagName)
+	binary, err := download(client, binaryURL)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	return binary, nil
+}
+
+func download(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// checksumFor finds a file's SHA-256 in shasum/sha256sum output.
+func checksumFor(checksums []byte, name string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0]
+		}
+	}
+	return ""
+}
@@ -6,6 +6,7 @@ BINARY_INSTALL_DIR="/usr/local/bin"
 BINARY_NAME="day-night-cycle"
 REPO="brittonhayes/day-night-cycle"
 PLIST_PATH="$HOME/Library/LaunchAgents/com.daynightcycle.schedule.plist"
+REFRESH_PLIST_PATH="$HOME/Library/LaunchAgents/com.daynightcycle.refresh.plist"
 
 # Handle uninstall
 if [ "$1" = "--uninstall" ]; then
@@ -21,6 +22,11 @@ if [ "$1" = "--uninstall" ]; then
         rm "$PLIST_PATH"
         echo "Removed: $PLIST_PATH"
     fi
+    if [ -f "$REFRESH_PLIST_PATH" ]; then
+        launchctl unload "$REFRESH_PLIST_PATH" 2>/dev/null || true
+        rm "$REFRESH_PLIST_PATH"
+        echo "Removed: $REFRESH_PLIST_PATH"
+    fi
 
     # Remove binary
     if [ -f "$BINARY_INSTALL_DIR/$BINARY_NAME" ]; then
@@ -140,6 +146,7 @@ if [ -z "$SKIP_CONFIG" ]; then
 
     cat > "$CONFIG_DIR/config.yaml" <<EOF
 # yaml-language-server: \$schema=https://raw.githubusercontent.com/brittonhayes/day-night-cycle/main/config.schema.json
+version: 2
 location:
   latitude: $latitude
   longitude: $longitude
@@ -185,27 +192,20 @@ EOF
     echo "You can edit this file later to customize plugin settings"
 fi
 
-# Generate launchd schedule
+# Generate and load the launchd schedule
 echo ""
-echo "Generating launchd schedule..."
-if ! "$BINARY_NAME" --config "$CONFIG_DIR/config.yaml" schedule; then
+echo "Setting up automatic scheduling..."
+if ! "$BINARY_NAME" --config "$CONFIG_DIR/config.yaml" schedule install; then
     echo ""
     echo "Error: Failed to generate launchd schedule"
     echo "Please check your configuration file at: ~/.config/day-night-cycle/config.yaml"
     echo "Make sure all values are properly set (latitude, longitude, timezone)"
     echo ""
     echo "You can manually edit the config and run:"
-    echo "  $BINARY_NAME --config ~/.config/day-night-cycle/config.yaml schedule"
+    echo "  $BINARY_NAME --config ~/.config/day-night-cycle/config.yaml schedule install"
     exit 1
 fi
 
-# Load launchd agent
-echo ""
-echo "Setting up automatic scheduling..."
-launchctl unload "$PLIST_PATH" 2>/dev/null || true
-launchctl load "$PLIST_PATH"
-echo "Automatic theme switching enabled"
-
 echo ""
 echo "==========================================="
 echo "Installation complete!"
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// SystemIsLight reads the OS appearance: AppleInterfaceStyle on macOS, the
+// freedesktop settings portal's color-scheme on Linux.
+func SystemIsLight() (bool, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		// The key only exists in dark mode.
+		output, err := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle").Output()
+		if err != nil {
+			return true, nil
+		}
+		return strings.TrimSpace(string(output)) != "Dark", nil
+	case "linux":
+		output, err := exec.Command("gdbus", "call", "--session",
+			"--dest", "org.freedesktop.portal.Desktop",
+			"--object-path", "/org/freedesktop/portal/desktop",
+			"--method", "org.freedesktop.portal.Settings.Read",
+			"org.freedesktop.appearance", "color-scheme").Output()
+		if err != nil {
+			return false, fmt.Errorf("reading color-scheme from the desktop portal: %w", err)
+		}
+		// Replies look like "(<<uint32 1>>,)": 1 prefers dark, 2 prefers
+		// light, 0 has no preference.
+		return !strings.Contains(string(output), "uint32 1>"), nil
+	}
+	return false, fmt.Errorf("following the system appearance is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache keeps API responses on disk so repeated runs don't hammer free
+// geolocation, weather, and geocoding APIs, and keep working offline.
+type Cache struct {
+	Dir    string
+	Client *http.Client
+}
+
+// CacheDir returns the default cache directory.
+func CacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "day-night-cycle")
+	}
+	return filepath.Join(dir, "day-night-cycle")
+}
+
+// retryInterval is how long Get waits after a request before making
+// another for the same URL, so frequent runs don't hammer an API that is
+// down or rate limiting.
+const retryInterval = 5 * time.Minute
+
+// Get returns the body of a GET request to url. A cached response younger
+// than ttl is returned without a request. If the request fails, the last
+// cached response is returned however old it is, with a warning on stderr,
+// and it keeps being returned without a request for retryInterval.
+func (c Cache) Get(url string, ttl time.Duration) ([]byte, error) {
+	sum := sha256.Sum256([]byte(url))
+	path := filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+	// The attempt file's mtime records the last request.
+	attempt := path + ".attempt"
+
+	info, statErr := os.Stat(path)
+	if statErr == nil && time.Since(info.ModTime()) < ttl {
+		return os.ReadFile(path)
+	}
+
+	if last, err := os.Stat(attempt); err == nil && time.Since(last.ModTime()) < retryInterval {
+		if statErr != nil {
+			return nil, fmt.Errorf("GET %s failed %s ago; retrying after %s", url, time.Since(last.ModTime()).Round(time.Second), retryInterval)
+		}
+		return os.ReadFile(path)
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	if err := os.WriteFile(attempt, nil, 0644); err != nil {
+		return nil, fmt.Errorf("writing cache: %w", err)
+	}
+
+	body, err := c.fetch(url)
+	if err != nil {
+		if statErr != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v; using response cached %s\n", err, info.ModTime().Format("Jan 2 3:04 PM"))
+		return os.ReadFile(path)
+	}
+
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		return nil, fmt.Errorf("writing cache: %w", err)
+	}
+	return body, nil
+}
+
+func (c Cache) fetch(url string) ([]byte, error) {
+	resp, err := c.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -1,9 +1,17 @@
 package internal
 
 import (
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/brittonhayes/day-night-cycle/plugins"
@@ -12,27 +20,182 @@ import (
 
 // Config represents the YAML configuration.
 type Config struct {
+	Version  int                 `yaml:"version,omitempty"`
 	Location LocationConfig      `yaml:"location"`
 	Plugins  []ConfigPluginEntry `yaml:"plugins"`
+
+	// Locations are saved places, e.g. home and office. The one this
+	// machine is at replaces Location: matched by the system timezone, or
+	// with LocationDetect "ip" by the nearest to the IP address's location.
+	Locations      map[string]LocationConfig `yaml:"locations,omitempty"`
+	LocationDetect string                    `yaml:"locationDetect,omitempty"`
+	Profiles       map[string]Profile        `yaml:"profiles,omitempty"`
+
+	// Theme is the pack plugin entries without their own theme take
+	// values from; Themes adds packs to the built-in ones.
+	Theme  string               `yaml:"theme,omitempty"`
+	Themes map[string]ThemePack `yaml:"themes,omitempty"`
+
+	// Accessibility swaps every plugin entry's values for its high-contrast
+	// variant, its own or a built-in one.
+	Accessibility bool `yaml:"accessibility,omitempty"`
+
+	// Mode "system" follows the OS appearance instead of the sun.
+	Mode string `yaml:"mode,omitempty"`
+
+	// PauseWhileSharing holds automatic transitions while the screen is
+	// shared, until sharing ends.
+	PauseWhileSharing bool `yaml:"pauseWhileSharing,omitempty"`
+	// SharingProcesses are more processes that only run while the screen
+	// is shared, for tools the built-in list doesn't know.
+	SharingProcesses []string `yaml:"sharingProcesses,omitempty"`
+
+	Shell ShellConfig `yaml:"shell,omitempty"`
+	HTTP  HTTPConfig  `yaml:"http,omitempty"`
+	Logs  LogConfig   `yaml:"logs,omitempty"`
+	Sync  SyncConfig  `yaml:"sync,omitempty"`
+	Fleet FleetConfig `yaml:"fleet,omitempty"`
+
+	httpClient   *http.Client
+	locationName string
+}
+
+// ShellConfig holds environment variables that shell-init exports for each
+// mode, e.g. BAT_THEME, for tools that are only themed through env vars.
+type ShellConfig struct {
+	Day   map[string]string `yaml:"day,omitempty"`
+	Night map[string]string `yaml:"night,omitempty"`
+}
+
+// Profile is a named plugin set that replaces the top-level plugins when
+// selected, e.g. "work" vs "streaming".
+type Profile struct {
+	Plugins []ConfigPluginEntry `yaml:"plugins"`
+}
+
+// SyncConfig is the git remote "config sync" shares the config directory
+// through. Branch defaults to "main".
+type SyncConfig struct {
+	Remote string `yaml:"remote,omitempty"`
+	Branch string `yaml:"branch,omitempty"`
+}
+
+// FleetConfig lists other machines that switch with this one. When Primary
+// is set, every change of mode also runs Command (default
+// "day-night-cycle") with the mode on each SSH host.
+type FleetConfig struct {
+	Primary bool     `yaml:"primary,omitempty"`
+	Hosts   []string `yaml:"hosts,omitempty"`
+	Command string   `yaml:"command,omitempty"`
 }
 
 // LocationConfig holds geographic location settings.
 type LocationConfig struct {
-	Latitude    float64 `yaml:"latitude"`
-	Longitude   float64 `yaml:"longitude"`
+	Latitude    float64 `yaml:"latitude,omitempty"`
+	Longitude   float64 `yaml:"longitude,omitempty"`
 	Timezone    string  `yaml:"timezone"`
 	DayOffset   string  `yaml:"dayOffset,omitempty"`
 	NightOffset string  `yaml:"nightOffset,omitempty"`
 
+	// Jitter moves each sunrise and sunset by up to this much either way,
+	// by an amount that is the same all day but differs between days and
+	// machines.
+	Jitter string `yaml:"jitter,omitempty"`
+
+	// DuskZenith enables dusk mode between the sun crossing this zenith
+	// angle and sunrise or sunset, e.g. 96 for civil twilight.
+	DuskZenith float64 `yaml:"duskZenith,omitempty"`
+
+	// Horizon maps compass directions (north, northeast, east, ...) to the
+	// altitude in degrees of terrain blocking the sun there, so sunrise and
+	// sunset are when the sun clears it.
+	Horizon map[string]float64 `yaml:"horizon,omitempty"`
+
+	// Provider picks where sunrise and sunset come from: "noaa" (the
+	// built-in calculation, default), "sunrise-sunset" (sunrise-sunset.org),
+	// or "table" (Table). If it fails, the built-in calculation is used.
+	Provider string        `yaml:"provider,omitempty"`
+	Table    []SunTableDay `yaml:"table,omitempty"`
+
+	// provider is nil for the built-in calculation.
+	provider SunProvider
+
+	// estimated is set when the coordinates come from the timezone.
+	estimated bool
+
+	// snoozed is a sunrise or sunset that happens at snoozedUntil instead.
+	snoozed      time.Time
+	snoozedUntil time.Time
+
 	dayOffsetDuration   time.Duration
 	nightOffsetDuration time.Duration
+	jitterDuration      time.Duration
 }
 
 // ConfigPluginEntry wraps plugins.PluginConfig with Name and Enabled fields for YAML config.
+// Label tells apart several entries for the same plugin; it defaults to Name.
+// DayOffset and NightOffset shift this plugin's transitions relative to the
+// location's, so one app can switch later than the rest. Mode pins the
+// plugin to "always-light" or "always-dark" regardless of the time or a
+// forced light/dark command. Entries whose When doesn't match this machine
+// are dropped at load time. RequiresRunning and RequiresClosed name a
+// process: the first skips the plugin while it isn't running, the second
+// defers the plugin until it quits. SkipOnBattery skips the plugin while
+// the machine is on battery. Reload runs after a successful apply.
+// Transition is a window centered on each transition over which gradual
+// plugins (brightness, color temperature) step between their values. Theme
+// names a theme pack the day, night, and dusk values default to, and
+// HighContrast holds the values accessibility mode uses instead.
 type ConfigPluginEntry struct {
-	Name    string `yaml:"name"`
-	Enabled bool   `yaml:"enabled"`
+	Name                 string       `yaml:"name"`
+	Label                string       `yaml:"label,omitempty"`
+	Enabled              bool         `yaml:"enabled"`
+	DayOffset            string       `yaml:"dayOffset,omitempty"`
+	NightOffset          string       `yaml:"nightOffset,omitempty"`
+	Mode                 string       `yaml:"mode,omitempty"`
+	When                 When         `yaml:"when,omitempty"`
+	RequiresRunning      string       `yaml:"requiresRunning,omitempty"`
+	RequiresClosed       string       `yaml:"requiresClosed,omitempty"`
+	SkipOnBattery        bool         `yaml:"skipOnBattery,omitempty"`
+	Reload               Reload       `yaml:"reload,omitempty"`
+	Transition           string       `yaml:"transition,omitempty"`
+	Theme                string       `yaml:"theme,omitempty"`
+	HighContrast         *ThemeValues `yaml:"highContrast,omitempty"`
 	plugins.PluginConfig `yaml:",inline"`
+
+	dayOffsetDuration   time.Duration
+	nightOffsetDuration time.Duration
+	transitionDuration  time.Duration
+}
+
+// When restricts a plugin entry to matching machines, so one config can be
+// shared across hosts. Hostname is a glob matched against the full and the
+// short host name; OS is a GOOS value such as "darwin" or "linux". Empty
+// fields match everything.
+type When struct {
+	Hostname string `yaml:"hostname,omitempty"`
+	OS       string `yaml:"os,omitempty"`
+}
+
+// Matches reports whether the condition holds on this machine.
+func (w When) Matches() bool {
+	if w.OS != "" && w.OS != runtime.GOOS {
+		return false
+	}
+	if w.Hostname == "" {
+		return true
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return false
+	}
+	short, _, _ := strings.Cut(hostname, ".")
+	for _, name := range []string{hostname, short} {
+		if ok, _ := path.Match(w.Hostname, name); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // DefaultPath returns the default configuration file path.
@@ -44,25 +207,184 @@ func DefaultPath() string {
 	return filepath.Join(home, ".config", "day-night-cycle", "config.yaml")
 }
 
-// Load reads and parses the configuration file.
-func Load(path string) (Config, error) {
+// HostOverlayPath returns this machine's overlay file, hosts/<short
+// hostname>.yaml next to the config. Only this machine writes it, so synced
+// machines never conflict over it.
+func HostOverlayPath(configPath string) string {
+	hostname, _ := os.Hostname()
+	short, _, _ := strings.Cut(hostname, ".")
+	return filepath.Join(filepath.Dir(configPath), "hosts", short+".yaml")
+}
+
+// Read parses the configuration file as written: nothing is validated and
+// entries for other machines are kept, e.g. for exporting it.
+func Read(path string) (Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return Config{}, fmt.Errorf("reading config: %w", err)
 	}
 
+	if err := checkVersion(data); err != nil {
+		return Config{}, err
+	}
+
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return Config{}, fmt.Errorf("parsing config: %w", err)
 	}
+	return cfg, nil
+}
+
+// Load reads, parses, and validates the configuration file. Settings in
+// this machine's overlay replace the config's: fields of objects like
+// location are replaced one by one, lists like plugins as a whole.
+func Load(path string) (Config, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	overlayPath := HostOverlayPath(path)
+	overlay, err := os.ReadFile(overlayPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, fmt.Errorf("reading host overlay: %w", err)
+	}
+	if err := yaml.Unmarshal(overlay, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing %s: %w", overlayPath, err)
+	}
+
+	cfg.httpClient, err = cfg.HTTP.Client()
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.selectLocation(); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Location.estimate(); err != nil {
+		return Config{}, err
+	}
 
 	if err := cfg.Location.parseOffsets(); err != nil {
 		return Config{}, fmt.Errorf("invalid location offsets: %w", err)
 	}
 
+	cfg.Location.provider, err = newProvider(cfg.Location, cfg.Cache())
+	if err != nil {
+		return Config{}, err
+	}
+
+	if cfg.Mode != "" && cfg.Mode != "system" {
+		return Config{}, fmt.Errorf("invalid mode %q: want system", cfg.Mode)
+	}
+
+	if cfg.Logs.MaxSize < 0 || cfg.Logs.MaxAge < 0 || cfg.Logs.Keep < 0 {
+		return Config{}, fmt.Errorf("invalid logs: maxSize, maxAge, and keep must not be negative")
+	}
+
+	if z := cfg.Location.DuskZenith; z != 0 && (z <= sunriseZenith || z > 108) {
+		return Config{}, fmt.Errorf("invalid duskZenith %v: want between %v and 108", z, sunriseZenith)
+	}
+
+	for direction, altitude := range cfg.Location.Horizon {
+		if !slices.Contains(compass, direction) {
+			return Config{}, fmt.Errorf("invalid horizon direction %q: want one of %s", direction, strings.Join(compass, ", "))
+		}
+		if altitude < 0 || altitude >= 90 {
+			return Config{}, fmt.Errorf("invalid horizon altitude %v for %s: want between 0 and 90", altitude, direction)
+		}
+	}
+
+	for _, vars := range []map[string]string{cfg.Shell.Day, cfg.Shell.Night} {
+		for name := range vars {
+			if !plugins.ValidEnvName(name) {
+				return Config{}, fmt.Errorf("invalid shell variable %q: want letters, digits, and underscores, not starting with a digit", name)
+			}
+		}
+	}
+
+	if err := cfg.applyThemes(cfg.Plugins); err != nil {
+		return Config{}, err
+	}
+	cfg.applyAccessibility(cfg.Plugins)
+	cfg.Plugins, err = preparePlugins(cfg.Plugins)
+	if err != nil {
+		return Config{}, err
+	}
+
+	for name, profile := range cfg.Profiles {
+		if err := cfg.applyThemes(profile.Plugins); err != nil {
+			return Config{}, fmt.Errorf("profile %s: %w", name, err)
+		}
+		cfg.applyAccessibility(profile.Plugins)
+		profile.Plugins, err = preparePlugins(profile.Plugins)
+		if err != nil {
+			return Config{}, fmt.Errorf("profile %s: %w", name, err)
+		}
+		cfg.Profiles[name] = profile
+	}
+
 	return cfg, nil
 }
 
+// preparePlugins drops entries meant for other machines, fills in default
+// labels, and validates what remains.
+func preparePlugins(all []ConfigPluginEntry) ([]ConfigPluginEntry, error) {
+	var entries []ConfigPluginEntry
+	for _, entry := range all {
+		if entry.When.Matches() {
+			entries = append(entries, entry)
+		}
+	}
+
+	labels := make(map[string]bool)
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Label == "" {
+			entry.Label = entry.Name
+		}
+		if labels[entry.Label] {
+			return nil, fmt.Errorf("duplicate plugin entry %q: give each %s entry a unique label", entry.Label, entry.Name)
+		}
+		labels[entry.Label] = true
+
+		if err := plugins.Validate(entry.Name, entry.Custom); err != nil {
+			return nil, fmt.Errorf("invalid plugin %s: %w", entry.Label, err)
+		}
+
+		if err := entry.parseOffsets(); err != nil {
+			return nil, fmt.Errorf("invalid offsets for plugin %s: %w", entry.Label, err)
+		}
+
+		if err := entry.Reload.validate(); err != nil {
+			return nil, fmt.Errorf("invalid reload for plugin %s: %w", entry.Label, err)
+		}
+
+		switch entry.Mode {
+		case "", "always-light", "always-dark":
+		default:
+			return nil, fmt.Errorf("invalid mode %q for plugin %s: want always-light or always-dark", entry.Mode, entry.Label)
+		}
+	}
+
+	return entries, nil
+}
+
+// UseProfile replaces the plugin list with the named profile's plugins. An
+// empty name keeps the top-level plugins.
+func (c *Config) UseProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	c.Plugins = profile.Plugins
+	return nil
+}
+
 // LoadLocation loads the timezone location.
 func LoadLocation(tz string) (*time.Location, error) {
 	loc, err := time.LoadLocation(tz)
@@ -72,6 +394,57 @@ func LoadLocation(tz string) (*time.Location, error) {
 	return loc, nil
 }
 
+// Twilight returns dawn and dusk on the day of t, when the sun crosses
+// the location's dusk zenith. Both are zero when dusk mode is disabled.
+func (c Config) Twilight(t time.Time) (dawn, dusk time.Time) {
+	if c.Location.DuskZenith == 0 {
+		return time.Time{}, time.Time{}
+	}
+	return CalculateTimesAt(c.Location.Latitude, c.Location.Longitude, t, c.Location.DuskZenith)
+}
+
+// InDusk reports whether now falls between dawn and sunrise or between
+// sunset and dusk.
+func (c Config) InDusk(now, sunrise, sunset time.Time) bool {
+	dawn, dusk := c.Twilight(now)
+	if dawn.IsZero() {
+		return false
+	}
+	return (!now.Before(dawn) && now.Before(sunrise)) || (!now.Before(sunset) && now.Before(dusk))
+}
+
+// HTTPClient returns the client network plugins and providers share.
+func (c Config) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// Cache returns the on-disk response cache providers fetch through.
+func (c Config) Cache() Cache {
+	return Cache{Dir: CacheDir(), Client: c.httpClient}
+}
+
+// SolarNoon returns when the sun is highest at the configured location.
+func (c Config) SolarNoon(t time.Time) time.Time {
+	return SolarNoon(c.Location.Latitude, c.Location.Longitude, t)
+}
+
+// DayLength returns the time between sunrise and sunset at the configured
+// location from the built-in calculation, ignoring offsets, the horizon
+// profile, and the provider.
+func (c Config) DayLength(t time.Time) time.Duration {
+	return DayLength(c.Location.Latitude, c.Location.Longitude, t)
+}
+
+// MoonTimes returns moonrise and moonset at the configured location.
+func (c Config) MoonTimes(t time.Time) (rise, set time.Time) {
+	return MoonTimes(c.Location.Latitude, c.Location.Longitude, t)
+}
+
+// Elevation returns the sun's elevation in degrees at the configured location.
+func (c Config) Elevation(t time.Time) float64 {
+	return Elevation(c.Location.Latitude, c.Location.Longitude, t)
+}
+
 // parseOffsets parses and validates the offset duration strings.
 func (lc *LocationConfig) parseOffsets() error {
 	if lc.DayOffset != "" {
@@ -90,10 +463,198 @@ func (lc *LocationConfig) parseOffsets() error {
 		lc.nightOffsetDuration = d
 	}
 
+	if lc.Jitter != "" {
+		d, err := time.ParseDuration(lc.Jitter)
+		if err != nil {
+			return fmt.Errorf("invalid jitter %q: %w", lc.Jitter, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("invalid jitter %q: must not be negative", lc.Jitter)
+		}
+		lc.jitterDuration = d
+	}
+
 	return nil
 }
 
-// ApplyOffsets applies the configured offsets to sunrise and sunset times.
+// ApplyOffsets applies the configured offsets and jitter to sunrise and
+// sunset times.
 func (lc LocationConfig) ApplyOffsets(sunrise, sunset time.Time) (time.Time, time.Time) {
-	return sunrise.Add(lc.dayOffsetDuration), sunset.Add(lc.nightOffsetDuration)
+	return sunrise.Add(lc.dayOffsetDuration + lc.jitter(sunrise, "sunrise")),
+		sunset.Add(lc.nightOffsetDuration + lc.jitter(sunset, "sunset"))
+}
+
+// jitter returns the transition's jitter on the day of t: whole seconds
+// within the configured jitter either way, hashed from the hostname and
+// date so every command, and every run that day, agrees on it.
+func (lc LocationConfig) jitter(t time.Time, transition string) time.Duration {
+	n := int64(lc.jitterDuration / time.Second)
+	if n == 0 {
+		return 0
+	}
+	host, _ := os.Hostname()
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s %s %s", host, t.Format(time.DateOnly), transition)
+	return time.Duration(int64(h.Sum64()%uint64(2*n+1))-n) * time.Second
+}
+
+// Times returns sunrise and sunset on the day of t for the configured
+// location, with the location offsets applied.
+func (c Config) Times(t time.Time) (sunrise, sunset time.Time) {
+	return c.Location.Times(t)
+}
+
+// Times returns sunrise and sunset on the day of t, when the sun clears the
+// horizon profile, with the offsets applied. Times for the days around
+// today are cached; others, e.g. a year ahead for "sun --year", are
+// calculated each time.
+func (lc LocationConfig) Times(t time.Time) (sunrise, sunset time.Time) {
+	date := t.Format("2006-01-02")
+	var cache sunTimes
+	key := lc.key(t)
+	if cacheable(date) {
+		cache = loadSunTimes()
+		if cached, ok := cache[key]; ok {
+			return lc.snooze(lc.ApplyOffsets(cached.Sunrise.In(t.Location()), cached.Sunset.In(t.Location())))
+		}
+	}
+
+	sunrise, sunset, fellBack := lc.providerTimes(t)
+
+	if len(lc.Horizon) > 0 {
+		noon := SolarNoon(lc.Latitude, lc.Longitude, t)
+		for sunrise.Before(noon) && !lc.clear(sunrise) {
+			sunrise = sunrise.Add(time.Minute)
+		}
+		for sunset.After(noon) && !lc.clear(sunset) {
+			sunset = sunset.Add(-time.Minute)
+		}
+	}
+
+	// The fallback's times would otherwise stand in for the provider's all
+	// day, so the provider is asked again next time.
+	if cache != nil && !fellBack {
+		cache[key] = cachedTimes{Date: date, Sunrise: sunrise, Sunset: sunset}
+		if err := cache.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: caching sunrise and sunset: %v\n", err)
+		}
+	}
+
+	return lc.snooze(lc.ApplyOffsets(sunrise, sunset))
+}
+
+// Snooze moves the transition at the given time to until, for every
+// command that uses the config's times.
+func (c *Config) Snooze(transition, until time.Time) {
+	c.Location.snoozed, c.Location.snoozedUntil = transition, until
+}
+
+// snooze replaces a snoozed sunrise or sunset with the time it was snoozed
+// until.
+func (lc LocationConfig) snooze(sunrise, sunset time.Time) (time.Time, time.Time) {
+	if lc.snoozed.IsZero() {
+		return sunrise, sunset
+	}
+	if sunrise.Equal(lc.snoozed) {
+		sunrise = lc.snoozedUntil
+	}
+	if sunset.Equal(lc.snoozed) {
+		sunset = lc.snoozedUntil
+	}
+	return sunrise, sunset
+}
+
+// providerTimes returns the provider's sunrise and sunset, falling back to
+// the built-in calculation if it fails, which it reports.
+func (lc LocationConfig) providerTimes(t time.Time) (sunrise, sunset time.Time, fellBack bool) {
+	if lc.provider != nil {
+		sunrise, sunset, err := lc.provider.Times(lc.Latitude, lc.Longitude, t)
+		if err == nil {
+			return sunrise, sunset, false
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v; using the built-in calculation\n", err)
+		fellBack = true
+	}
+	sunrise, sunset = CalculateTimes(lc.Latitude, lc.Longitude, t)
+	return sunrise, sunset, fellBack
+}
+
+// compass lists the horizon directions, each covering 45° of azimuth.
+var compass = []string{"north", "northeast", "east", "southeast", "south", "southwest", "west", "northwest"}
+
+// clear reports whether the top of the sun is above the horizon profile
+// at t. Like sunrise, it allows for refraction and the sun's radius.
+func (lc LocationConfig) clear(t time.Time) bool {
+	elevation, azimuth := Position(lc.Latitude, lc.Longitude, t)
+	direction := compass[int(math.Round(azimuth/45.0))%8]
+	return elevation > lc.Horizon[direction]-(sunriseZenith-90.0)
+}
+
+// parseOffsets parses and validates the plugin's offset duration strings.
+func (e *ConfigPluginEntry) parseOffsets() error {
+	if e.DayOffset != "" {
+		d, err := time.ParseDuration(e.DayOffset)
+		if err != nil {
+			return fmt.Errorf("invalid dayOffset %q: %w", e.DayOffset, err)
+		}
+		e.dayOffsetDuration = d
+	}
+
+	if e.NightOffset != "" {
+		d, err := time.ParseDuration(e.NightOffset)
+		if err != nil {
+			return fmt.Errorf("invalid nightOffset %q: %w", e.NightOffset, err)
+		}
+		e.nightOffsetDuration = d
+	}
+
+	if e.Transition != "" {
+		d, err := time.ParseDuration(e.Transition)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid transition %q: want a positive duration", e.Transition)
+		}
+		e.transitionDuration = d
+	}
+
+	return nil
+}
+
+// ApplyOffsets applies the plugin's offsets on top of times that already
+// include the location offsets.
+func (e ConfigPluginEntry) ApplyOffsets(sunrise, sunset time.Time) (time.Time, time.Time) {
+	return sunrise.Add(e.dayOffsetDuration), sunset.Add(e.nightOffsetDuration)
+}
+
+// IsLight reports whether the plugin should be in day mode at now.
+func (e ConfigPluginEntry) IsLight(now, sunrise, sunset time.Time) bool {
+	sunrise, sunset = e.ApplyOffsets(sunrise, sunset)
+	return now.After(sunrise) && now.Before(sunset)
+}
+
+// Level returns the plugin's progress from night (0) to day (1) at now. It
+// ramps linearly across the transition window, centered on the plugin's
+// sunrise and sunset, and is exactly 0 or 1 outside it.
+func (e ConfigPluginEntry) Level(now, sunrise, sunset time.Time) float64 {
+	sunrise, sunset = e.ApplyOffsets(sunrise, sunset)
+	half := e.transitionDuration / 2
+	window := float64(e.transitionDuration)
+
+	switch {
+	case now.Before(sunrise.Add(-half)):
+		return 0
+	case window > 0 && now.Before(sunrise.Add(half)):
+		return float64(now.Sub(sunrise.Add(-half))) / window
+	case now.Before(sunset.Add(-half)):
+		return 1
+	case window > 0 && now.Before(sunset.Add(half)):
+		return float64(sunset.Add(half).Sub(now)) / window
+	}
+	return 0
+}
+
+// InTransition reports whether now falls inside one of the plugin's
+// transition windows.
+func (e ConfigPluginEntry) InTransition(now, sunrise, sunset time.Time) bool {
+	level := e.Level(now, sunrise, sunset)
+	return level > 0 && level < 1
 }
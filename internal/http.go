@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/brittonhayes/day-night-cycle/plugins"
+)
+
+// HTTPConfig configures the HTTP client shared by network plugins and
+// location or weather providers, for networks that need a proxy or their
+// own certificate authority.
+type HTTPConfig struct {
+	// Proxy is a proxy URL; by default HTTPS_PROXY and friends are used.
+	Proxy string `yaml:"proxy,omitempty"`
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string `yaml:"caFile,omitempty"`
+	// Timeout bounds each request; it defaults to 10s.
+	Timeout string `yaml:"timeout,omitempty"`
+	// InsecureSkipVerify lists hosts or IP addresses whose certificates
+	// aren't verified, e.g. a home server with a self-signed certificate.
+	// It doesn't apply to requests sent through a proxy.
+	InsecureSkipVerify []string `yaml:"insecureSkipVerify,omitempty"`
+}
+
+// Client builds the HTTP client described by the config.
+func (h HTTPConfig) Client() (*http.Client, error) {
+	timeout := 10 * time.Second
+	if h.Timeout != "" {
+		d, err := time.ParseDuration(h.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid http timeout %q: want a positive duration, e.g. 10s", h.Timeout)
+		}
+		timeout = d
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if h.Proxy != "" {
+		proxy, err := url.Parse(h.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid http proxy %q: %w", h.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if h.CAFile != "" {
+		path, err := plugins.ExpandPath(h.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading http caFile: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("http caFile %s has no PEM certificates", h.CAFile)
+		}
+	}
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+
+	// Go can only turn verification off for a whole client, so hosts that
+	// skip it get their own config, keyed by the host actually dialed.
+	// Connections through a proxy are always verified.
+	if len(h.InsecureSkipVerify) > 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			config := transport.TLSClientConfig.Clone()
+			config.ServerName = host
+			config.InsecureSkipVerify = slices.Contains(h.InsecureSkipVerify, host)
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			tlsConn := tls.Client(conn, config)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		}
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
@@ -0,0 +1,290 @@
+package internal
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// language holds one language's translations of the status, next, and
+// schedule output, and how it writes times and dates.
+type language struct {
+	messages map[string]string
+	// layouts maps the English layouts output uses to this language's.
+	layouts  map[string]string
+	weekdays [7]string  // Full names, Sunday first
+	days     [7]string  // Short names
+	months   [12]string // Full names
+	mons     [12]string // Short names
+}
+
+// locale is the user's language, from LC_ALL, LC_MESSAGES, or LANG, or nil
+// for English.
+var locale = detectLanguage()
+
+func detectLanguage() *language {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		// de_DE.UTF-8, fr_CA, es; C and POSIX are English.
+		lang, _, _ := strings.Cut(value, "_")
+		lang, _, _ = strings.Cut(lang, ".")
+		return languages[lang]
+	}
+	return nil
+}
+
+// T returns msg, a message or format string, in the user's language. Messages
+// without a translation are returned as they are.
+func T(msg string) string {
+	if locale == nil {
+		return msg
+	}
+	if translated, ok := locale.messages[msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// FormatTime is t.Format(layout) in the user's language: its clock, date
+// order, and weekday and month names. Layouts are the English ones the
+// output uses, like "3:04 PM" or "Mon Jan 2".
+func FormatTime(t time.Time, layout string) string {
+	if locale == nil {
+		return t.Format(layout)
+	}
+	if local, ok := locale.layouts[layout]; ok {
+		layout = local
+	}
+	// Names are swapped in after formatting, through placeholders Format
+	// leaves alone.
+	layout = strings.NewReplacer("Monday", "\x01", "Mon", "\x02", "January", "\x03", "Jan", "\x04").Replace(layout)
+	return strings.NewReplacer(
+		"\x01", locale.weekdays[t.Weekday()],
+		"\x02", locale.days[t.Weekday()],
+		"\x03", locale.months[t.Month()-1],
+		"\x04", locale.mons[t.Month()-1],
+	).Replace(t.Format(layout))
+}
+
+// languages holds the translations, by ISO 639-1 code.
+var languages = map[string]*language{
+	"de": {
+		layouts: map[string]string{
+			"3:04 PM":                 "15:04",
+			"Mon Jan 2":               "Mon 2. Jan",
+			"Mon Jan 2 3:04 PM":       "Mon 2. Jan 15:04",
+			"Monday, January 2, 2006": "Monday, 2. January 2006",
+			"Monday, January 2":       "Monday, 2. January",
+		},
+		weekdays: [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		days:     [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		months:   [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		mons:     [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		messages: map[string]string{
+			"light":                    "hell",
+			"dark":                     "dunkel",
+			"dusk":                     "Dämmerung",
+			"light (following system)": "hell (folgt dem System)",
+			"dark (following system)":  "dunkel (folgt dem System)",
+			"sunrise":                  "Sonnenaufgang",
+			"sunset":                   "Sonnenuntergang",
+			"March equinox":            "März-Tagundnachtgleiche",
+			"June solstice":            "Juni-Sonnenwende",
+			"September equinox":        "September-Tagundnachtgleiche",
+			"December solstice":        "Dezember-Sonnenwende",
+
+			"\nCurrent mode: %s\n": "\nAktueller Modus: %s\n",
+			"Location: %s\n":       "Ort: %s\n",
+			"Location: estimated from %s (%.2f, %.2f); times may be 15 minutes or more off, set latitude and longitude to fix\n": "Ort: geschätzt aus %s (%.2f, %.2f); die Zeiten können 15 Minuten oder mehr abweichen, latitude und longitude setzen, um das zu beheben\n",
+			"Sunrise: %s (offset: %s)\n":                                         "Sonnenaufgang: %s (Versatz: %s)\n",
+			"Sunrise: %s\n":                                                      "Sonnenaufgang: %s\n",
+			"Sunset: %s (offset: %s)\n":                                          "Sonnenuntergang: %s (Versatz: %s)\n",
+			"Sunset: %s\n":                                                       "Sonnenuntergang: %s\n",
+			"Dawn: %s, Dusk: %s\n":                                               "Morgendämmerung: %s, Abenddämmerung: %s\n",
+			"Next transition: %s (%s in %s)\n":                                   "Nächster Wechsel: %s (%s in %s)\n",
+			"Sun elevation: %.1f°\n":                                             "Sonnenhöhe: %.1f°\n",
+			"Solar noon: %s\n":                                                   "Sonnenhöchststand: %s\n",
+			"Day length: %s (%s longer than yesterday)\n":                        "Tageslänge: %s (%s länger als gestern)\n",
+			"Day length: %s (%s shorter than yesterday)\n":                       "Tageslänge: %s (%s kürzer als gestern)\n",
+			"Next %s: %s (in %d days)\n":                                         "Nächste %s: %s (in %d Tagen)\n",
+			"  Day and night are about equal: %s of daylight\n":                  "  Tag und Nacht sind etwa gleich lang: %s Tageslicht\n",
+			"  Longest day of the year here: %s\n":                               "  Längster Tag des Jahres hier: %s\n",
+			"  Shortest day of the year here: %s\n":                              "  Kürzester Tag des Jahres hier: %s\n",
+			"Deferred: %s\n":                                                     "Zurückgestellt: %s\n",
+			"Held: the screen is shared, so transitions wait until sharing ends": "Angehalten: der Bildschirm wird geteilt, Wechsel warten bis zum Ende der Freigabe",
+			"Snoozed: the %s transition until %s\n":                              "Verschoben: der Wechsel um %s bis %s\n",
+			"\nConfigured plugins:":                                              "\nKonfigurierte Plugins:",
+			"  ◦ %s (%s only, skipped)\n":                                        "  ◦ %s (nur %s, übersprungen)\n",
+			"  ◦ %s (not detected, skipped)\n":                                   "  ◦ %s (nicht gefunden, übersprungen)\n",
+			"  • %s (day %s, night %s)\n":                                        "  • %s (Tag %s, Nacht %s)\n",
+			"Catching up on missed %s in the background\n":                       "Verpassten %s im Hintergrund nachholen\n",
+
+			"Daemon: running\n":                     "Daemon: läuft\n",
+			"  Applied mode: %s\n":                  "  Angewandter Modus: %s\n",
+			"%s (forced until the next transition)": "%s (erzwungen bis zum nächsten Wechsel)",
+			"  Automatic switching: paused\n":       "  Automatischer Wechsel: pausiert\n",
+
+			"\nLaunchd schedule created successfully\n": "\nLaunchd-Zeitplan erstellt\n",
+			"\nSchedule for %s:\n":                      "\nZeitplan für %s:\n",
+			"  Sunrise: %s\n":                           "  Sonnenaufgang:   %s\n",
+			"  Sunset:  %s\n":                           "  Sonnenuntergang: %s\n",
+			"  Plugins: %s\n":                           "  Plugins:         %s\n",
+			"  Covers:  %d days, through %s\n":          "  Umfasst:         %d Tage, bis %s\n",
+			"\nPlist file: %s\n":                        "\nPlist-Datei: %s\n",
+			"Daily refresh: %s\n":                       "Tägliche Aktualisierung: %s\n",
+			"Logs directory: %s\n":                      "Log-Verzeichnis: %s\n",
+			"loaded":                                    "geladen",
+			"not installed":                             "nicht installiert",
+			"installed, not loaded":                     "installiert, nicht geladen",
+			"Last run: %s\n":                            "Letzter Lauf: %s\n",
+			"Last run: never":                           "Letzter Lauf: nie",
+		},
+	},
+	"fr": {
+		layouts: map[string]string{
+			"3:04 PM":                 "15:04",
+			"Mon Jan 2":               "Mon 2 Jan",
+			"Mon Jan 2 3:04 PM":       "Mon 2 Jan 15:04",
+			"Monday, January 2, 2006": "Monday 2 January 2006",
+			"Monday, January 2":       "Monday 2 January",
+		},
+		weekdays: [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		days:     [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		months:   [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		mons:     [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		messages: map[string]string{
+			"light":                    "clair",
+			"dark":                     "sombre",
+			"dusk":                     "crépuscule",
+			"light (following system)": "clair (suit le système)",
+			"dark (following system)":  "sombre (suit le système)",
+			"sunrise":                  "lever du soleil",
+			"sunset":                   "coucher du soleil",
+			"March equinox":            "équinoxe de mars",
+			"June solstice":            "solstice de juin",
+			"September equinox":        "équinoxe de septembre",
+			"December solstice":        "solstice de décembre",
+
+			"\nCurrent mode: %s\n": "\nMode actuel : %s\n",
+			"Location: %s\n":       "Lieu : %s\n",
+			"Location: estimated from %s (%.2f, %.2f); times may be 15 minutes or more off, set latitude and longitude to fix\n": "Lieu : estimé d'après %s (%.2f, %.2f) ; les heures peuvent être décalées de 15 minutes ou plus, définissez latitude et longitude pour corriger\n",
+			"Sunrise: %s (offset: %s)\n":                                         "Lever du soleil : %s (décalage : %s)\n",
+			"Sunrise: %s\n":                                                      "Lever du soleil : %s\n",
+			"Sunset: %s (offset: %s)\n":                                          "Coucher du soleil : %s (décalage : %s)\n",
+			"Sunset: %s\n":                                                       "Coucher du soleil : %s\n",
+			"Dawn: %s, Dusk: %s\n":                                               "Aube : %s, crépuscule : %s\n",
+			"Next transition: %s (%s in %s)\n":                                   "Prochain changement : %s (%s dans %s)\n",
+			"Sun elevation: %.1f°\n":                                             "Hauteur du soleil : %.1f°\n",
+			"Solar noon: %s\n":                                                   "Midi solaire : %s\n",
+			"Day length: %s (%s longer than yesterday)\n":                        "Durée du jour : %s (%s de plus qu'hier)\n",
+			"Day length: %s (%s shorter than yesterday)\n":                       "Durée du jour : %s (%s de moins qu'hier)\n",
+			"Next %s: %s (in %d days)\n":                                         "Prochain %s : %s (dans %d jours)\n",
+			"  Day and night are about equal: %s of daylight\n":                  "  Le jour et la nuit sont à peu près égaux : %s de jour\n",
+			"  Longest day of the year here: %s\n":                               "  Jour le plus long de l'année ici : %s\n",
+			"  Shortest day of the year here: %s\n":                              "  Jour le plus court de l'année ici : %s\n",
+			"Deferred: %s\n":                                                     "Reportés : %s\n",
+			"Held: the screen is shared, so transitions wait until sharing ends": "En attente : l'écran est partagé, les changements attendent la fin du partage",
+			"Snoozed: the %s transition until %s\n":                              "Repoussé : le changement de %s jusqu'à %s\n",
+			"\nConfigured plugins:":                                              "\nPlugins configurés :",
+			"  ◦ %s (%s only, skipped)\n":                                        "  ◦ %s (%s uniquement, ignoré)\n",
+			"  ◦ %s (not detected, skipped)\n":                                   "  ◦ %s (non détecté, ignoré)\n",
+			"  • %s (day %s, night %s)\n":                                        "  • %s (jour %s, nuit %s)\n",
+			"Catching up on missed %s in the background\n":                       "Rattrapage du %s manqué en arrière-plan\n",
+
+			"Daemon: running\n":                     "Démon : actif\n",
+			"  Applied mode: %s\n":                  "  Mode appliqué : %s\n",
+			"%s (forced until the next transition)": "%s (forcé jusqu'au prochain changement)",
+			"  Automatic switching: paused\n":       "  Changement automatique : en pause\n",
+
+			"\nLaunchd schedule created successfully\n": "\nPlanification launchd créée\n",
+			"\nSchedule for %s:\n":                      "\nPlanification du %s :\n",
+			"  Sunrise: %s\n":                           "  Lever du soleil :   %s\n",
+			"  Sunset:  %s\n":                           "  Coucher du soleil : %s\n",
+			"  Plugins: %s\n":                           "  Plugins :           %s\n",
+			"  Covers:  %d days, through %s\n":          "  Couvre :            %d jours, jusqu'au %s\n",
+			"\nPlist file: %s\n":                        "\nFichier plist : %s\n",
+			"Daily refresh: %s\n":                       "Actualisation quotidienne : %s\n",
+			"Logs directory: %s\n":                      "Dossier des journaux : %s\n",
+			"loaded":                                    "chargé",
+			"not installed":                             "non installé",
+			"installed, not loaded":                     "installé, non chargé",
+			"Last run: %s\n":                            "Dernière exécution : %s\n",
+			"Last run: never":                           "Dernière exécution : jamais",
+		},
+	},
+	"es": {
+		layouts: map[string]string{
+			"3:04 PM":                 "15:04",
+			"Mon Jan 2":               "Mon 2 Jan",
+			"Mon Jan 2 3:04 PM":       "Mon 2 Jan 15:04",
+			"Monday, January 2, 2006": "Monday, 2 de January de 2006",
+			"Monday, January 2":       "Monday, 2 de January",
+		},
+		weekdays: [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		days:     [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		months:   [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		mons:     [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		messages: map[string]string{
+			"light":                    "claro",
+			"dark":                     "oscuro",
+			"dusk":                     "crepúsculo",
+			"light (following system)": "claro (sigue al sistema)",
+			"dark (following system)":  "oscuro (sigue al sistema)",
+			"sunrise":                  "amanecer",
+			"sunset":                   "atardecer",
+			"March equinox":            "equinoccio de marzo",
+			"June solstice":            "solsticio de junio",
+			"September equinox":        "equinoccio de septiembre",
+			"December solstice":        "solsticio de diciembre",
+
+			"\nCurrent mode: %s\n": "\nModo actual: %s\n",
+			"Location: %s\n":       "Ubicación: %s\n",
+			"Location: estimated from %s (%.2f, %.2f); times may be 15 minutes or more off, set latitude and longitude to fix\n": "Ubicación: estimada a partir de %s (%.2f, %.2f); las horas pueden variar 15 minutos o más, define latitude y longitude para corregirlo\n",
+			"Sunrise: %s (offset: %s)\n":                                         "Amanecer: %s (desfase: %s)\n",
+			"Sunrise: %s\n":                                                      "Amanecer: %s\n",
+			"Sunset: %s (offset: %s)\n":                                          "Atardecer: %s (desfase: %s)\n",
+			"Sunset: %s\n":                                                       "Atardecer: %s\n",
+			"Dawn: %s, Dusk: %s\n":                                               "Alba: %s, crepúsculo: %s\n",
+			"Next transition: %s (%s in %s)\n":                                   "Próximo cambio: %s (%s en %s)\n",
+			"Sun elevation: %.1f°\n":                                             "Altura del sol: %.1f°\n",
+			"Solar noon: %s\n":                                                   "Mediodía solar: %s\n",
+			"Day length: %s (%s longer than yesterday)\n":                        "Duración del día: %s (%s más que ayer)\n",
+			"Day length: %s (%s shorter than yesterday)\n":                       "Duración del día: %s (%s menos que ayer)\n",
+			"Next %s: %s (in %d days)\n":                                         "Próximo %s: %s (en %d días)\n",
+			"  Day and night are about equal: %s of daylight\n":                  "  El día y la noche duran casi lo mismo: %s de luz\n",
+			"  Longest day of the year here: %s\n":                               "  Día más largo del año aquí: %s\n",
+			"  Shortest day of the year here: %s\n":                              "  Día más corto del año aquí: %s\n",
+			"Deferred: %s\n":                                                     "Aplazados: %s\n",
+			"Held: the screen is shared, so transitions wait until sharing ends": "En espera: la pantalla está compartida, los cambios esperan a que termine",
+			"Snoozed: the %s transition until %s\n":                              "Pospuesto: el cambio de las %s hasta las %s\n",
+			"\nConfigured plugins:":                                              "\nPlugins configurados:",
+			"  ◦ %s (%s only, skipped)\n":                                        "  ◦ %s (solo %s, omitido)\n",
+			"  ◦ %s (not detected, skipped)\n":                                   "  ◦ %s (no detectado, omitido)\n",
+			"  • %s (day %s, night %s)\n":                                        "  • %s (día %s, noche %s)\n",
+			"Catching up on missed %s in the background\n":                       "Recuperando el %s perdido en segundo plano\n",
+
+			"Daemon: running\n":                     "Daemon: en ejecución\n",
+			"  Applied mode: %s\n":                  "  Modo aplicado: %s\n",
+			"%s (forced until the next transition)": "%s (forzado hasta el próximo cambio)",
+			"  Automatic switching: paused\n":       "  Cambio automático: en pausa\n",
+
+			"\nLaunchd schedule created successfully\n": "\nProgramación de launchd creada\n",
+			"\nSchedule for %s:\n":                      "\nProgramación para el %s:\n",
+			"  Sunrise: %s\n":                           "  Amanecer:  %s\n",
+			"  Sunset:  %s\n":                           "  Atardecer: %s\n",
+			"  Plugins: %s\n":                           "  Plugins:   %s\n",
+			"  Covers:  %d days, through %s\n":          "  Cubre:     %d días, hasta el %s\n",
+			"\nPlist file: %s\n":                        "\nArchivo plist: %s\n",
+			"Daily refresh: %s\n":                       "Actualización diaria: %s\n",
+			"Logs directory: %s\n":                      "Carpeta de registros: %s\n",
+			"loaded":                                    "cargado",
+			"not installed":                             "no instalado",
+			"installed, not loaded":                     "instalado, no cargado",
+			"Last run: %s\n":                            "Última ejecución: %s\n",
+			"Last run: never":                           "Última ejecución: nunca",
+		},
+	},
+}
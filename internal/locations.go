@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nearbyKm is how close the IP location must be to a saved location to
+// pick it.
+const nearbyKm = 100
+
+// selectLocation replaces Location with the saved location for where this
+// machine is: with locationDetect "ip" the one nearest its IP address's
+// location, otherwise, or if none is nearby, the first by name whose
+// timezone is the system timezone. With no match Location is kept.
+func (c *Config) selectLocation() error {
+	if len(c.Locations) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(c.Locations))
+	for name := range c.Locations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	switch c.LocationDetect {
+	case "", "timezone":
+	case "ip":
+		lat, lon, err := ipLocation(c.Cache())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v; matching the system timezone\n", err)
+			break
+		}
+		best, bestKm := "", math.Inf(1)
+		for _, name := range names {
+			loc := c.Locations[name]
+			if km := distanceKm(lat, lon, loc.Latitude, loc.Longitude); km < bestKm {
+				best, bestKm = name, km
+			}
+		}
+		if bestKm <= nearbyKm {
+			c.Location, c.locationName = c.Locations[best], best
+			return nil
+		}
+	default:
+		return fmt.Errorf("invalid locationDetect %q: want timezone or ip", c.LocationDetect)
+	}
+
+	tz := SystemTimezone()
+	for _, name := range names {
+		if c.Locations[name].Timezone == tz {
+			c.Location, c.locationName = c.Locations[name], name
+			return nil
+		}
+	}
+	return nil
+}
+
+// LocationName returns the name of the saved location in use, or "" for
+// the top-level location.
+func (c Config) LocationName() string {
+	return c.locationName
+}
+
+// SystemTimezone returns the IANA name of the system timezone: $TZ, or
+// where /etc/localtime points. It returns "" if neither names one.
+func SystemTimezone() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		return tz
+	}
+	target, err := filepath.EvalSymlinks("/etc/localtime")
+	if err != nil {
+		return ""
+	}
+	_, tz, found := strings.Cut(target, "zoneinfo/")
+	if !found {
+		return ""
+	}
+	return tz
+}
+
+// ipLocation returns the approximate coordinates of this machine's public
+// IP address from ipapi.co, cached for an hour so every run doesn't ask.
+func ipLocation(cache Cache) (lat, lon float64, err error) {
+	body, err := cache.Get("https://ipapi.co/json/", time.Hour)
+	if err != nil {
+		return 0, 0, fmt.Errorf("looking up IP location: %w", err)
+	}
+
+	var resp struct {
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+		Reason    string   `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, 0, fmt.Errorf("parsing IP location: %w", err)
+	}
+	if resp.Latitude == nil || resp.Longitude == nil {
+		return 0, 0, fmt.Errorf("looking up IP location: %s", resp.Reason)
+	}
+	return *resp.Latitude, *resp.Longitude, nil
+}
+
+// distanceKm returns the great-circle distance between two points.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	sinLat := math.Sin((lat2 - lat1) * rad / 2)
+	sinLon := math.Sin((lon2 - lon1) * rad / 2)
+	a := sinLat*sinLat + math.Cos(lat1*rad)*math.Cos(lat2*rad)*sinLon*sinLon
+	return 2 * 6371 * math.Asin(math.Sqrt(a))
+}
+
+// zoneinfoDir holds the system's timezone tables.
+const zoneinfoDir = "/usr/share/zoneinfo"
+
+// estimate fills in coordinates missing from the config with those of the
+// timezone's reference city in the system's zone tables, e.g. Los Angeles
+// for America/Los_Angeles. Times can then be 15 minutes or more off.
+func (lc *LocationConfig) estimate() error {
+	if lc.Latitude != 0 || lc.Longitude != 0 {
+		return nil
+	}
+
+	for _, table := range []string{"zone1970.tab", "zone.tab"} {
+		data, err := os.ReadFile(filepath.Join(zoneinfoDir, table))
+		if err != nil {
+			continue
+		}
+		for line := range strings.SplitSeq(string(data), "\n") {
+			fields := strings.Split(line, "\t")
+			if len(fields) < 3 || fields[2] != lc.Timezone {
+				continue
+			}
+			lc.Latitude, lc.Longitude, err = parseCoordinates(fields[1])
+			if err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+			lc.estimated = true
+			return nil
+		}
+	}
+	return fmt.Errorf("no latitude and longitude set, and no reference city known for timezone %q", lc.Timezone)
+}
+
+// Estimated reports whether the coordinates were estimated from the
+// timezone.
+func (lc LocationConfig) Estimated() bool {
+	return lc.estimated
+}
+
+// parseCoordinates parses zone table coordinates like "+340308-1181434":
+// latitude then longitude, each as degrees, minutes, and optional seconds.
+func parseCoordinates(s string) (lat, lon float64, err error) {
+	i := strings.IndexAny(s[1:], "+-") + 1
+	if i == 0 {
+		return 0, 0, fmt.Errorf("invalid coordinates %q", s)
+	}
+	if lat, err = parseDegrees(s[:i], 2); err != nil {
+		return 0, 0, err
+	}
+	if lon, err = parseDegrees(s[i:], 3); err != nil {
+		return 0, 0, err
+	}
+	return lat, lon, nil
+}
+
+// parseDegrees parses a signed angle whose degrees take the given number
+// of digits, e.g. "+3403" or "-1181434".
+func parseDegrees(s string, digits int) (float64, error) {
+	sign, s := s[:1], s[1:]
+	if len(s) != digits+2 && len(s) != digits+4 {
+		return 0, fmt.Errorf("invalid coordinate %q", sign+s)
+	}
+
+	var parts []float64
+	for _, part := range []string{s[:digits], s[digits : digits+2], s[digits+2:]} {
+		if part == "" {
+			part = "0"
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid coordinate %q", sign+s)
+		}
+		parts = append(parts, float64(v))
+	}
+
+	degrees := parts[0] + parts[1]/60 + parts[2]/3600
+	if sign == "-" {
+		degrees = -degrees
+	}
+	return degrees, nil
+}
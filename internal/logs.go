@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// LogConfig limits how much the launchd job logs keep. Zero values use the
+// defaults.
+type LogConfig struct {
+	MaxSize int `yaml:"maxSize,omitempty"` // Megabytes before a log is rotated (default 1)
+	MaxAge  int `yaml:"maxAge,omitempty"`  // Days rotated logs are kept (default 30)
+	Keep    int `yaml:"keep,omitempty"`    // Rotated files kept per log (default 3)
+
+	// System also sends run output to the macOS unified log or to
+	// syslog/journald on Linux.
+	System bool `yaml:"system,omitempty"`
+}
+
+// SystemLog sends a message tagged day-night-cycle to the macOS unified
+// log or to syslog, which journald collects, on Linux. Failures are logged
+// at error priority.
+func SystemLog(message string, failure bool) error {
+	priority := "user.info"
+	if failure {
+		priority = "user.err"
+	}
+
+	switch runtime.GOOS {
+	case "darwin", "linux":
+		if output, err := exec.Command("logger", "-t", "day-night-cycle", "-p", priority, message).CombinedOutput(); err != nil {
+			return fmt.Errorf("logging to the system log: %w: %s", err, output)
+		}
+		return nil
+	}
+	return fmt.Errorf("system logging is not supported on %s", runtime.GOOS)
+}
+
+// Rotate renames each log in dir that outgrew MaxSize to name.log.1,
+// shifting older rotations up, and deletes rotations beyond Keep or older
+// than MaxAge. launchd reopens the logs on every run, so the next run
+// starts a fresh file.
+func (lc LogConfig) Rotate(dir string) error {
+	maxSize, maxAge, keep := lc.MaxSize, lc.MaxAge, lc.Keep
+	if maxSize == 0 {
+		maxSize = 1
+	}
+	if maxAge == 0 {
+		maxAge = 30
+	}
+	if keep == 0 {
+		keep = 3
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("rotating logs: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("rotating logs: %w", err)
+		}
+		if info.Size() < int64(maxSize)<<20 {
+			continue
+		}
+		if err := rotate(filepath.Join(dir, entry.Name()), keep); err != nil {
+			return fmt.Errorf("rotating logs: %w", err)
+		}
+	}
+
+	// Rotation renamed files, so list them again to prune old ones.
+	rotated, err := filepath.Glob(filepath.Join(dir, "*.log.*"))
+	if err != nil {
+		return err
+	}
+	oldest := time.Now().AddDate(0, 0, -maxAge)
+	for _, path := range rotated {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("rotating logs: %w", err)
+		}
+		if info.ModTime().Before(oldest) {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("rotating logs: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// rotate renames path to path.1, shifting existing rotations up and
+// dropping the one past keep.
+func rotate(path string, keep int) error {
+	if err := os.Remove(fmt.Sprintf("%s.%d", path, keep)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := keep - 1; i >= 1; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(path, path+".1")
+}
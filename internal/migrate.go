@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigVersion is the config layout this build reads. Version 1 had a
+// plugins mapping keyed by name, with plugin-specific keys inline next to
+// day and night, and a location name.
+const ConfigVersion = 2
+
+// entryKeys are the keys a plugin entry understands; anything else on a
+// version 1 entry moves under custom.
+var entryKeys = map[string]bool{
+	"name": true, "label": true, "enabled": true, "dayOffset": true,
+	"nightOffset": true, "mode": true, "when": true, "requiresRunning": true,
+	"requiresClosed": true, "reload": true, "transition": true,
+	"day": true, "night": true, "dusk": true, "custom": true,
+}
+
+// configVersion returns the layout version of a parsed config document.
+// Configs without a version field are version 1 if they use the old
+// plugins mapping or location name.
+func configVersion(root *yaml.Node) (int, error) {
+	if v := lookup(root, "version"); v != nil {
+		n, err := strconv.Atoi(v.Value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid version %q", v.Value)
+		}
+		return n, nil
+	}
+	if p := lookup(root, "plugins"); p != nil && p.Kind == yaml.MappingNode {
+		return 1, nil
+	}
+	if l := lookup(root, "location"); l != nil && lookup(l, "name") != nil {
+		return 1, nil
+	}
+	return ConfigVersion, nil
+}
+
+// checkVersion rejects configs in a layout this build would misparse.
+func checkVersion(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		// Left for the real parse to report.
+		return nil
+	}
+	v, err := configVersion(doc.Content[0])
+	if err != nil {
+		return err
+	}
+	if v < ConfigVersion {
+		return fmt.Errorf("config is version %d, this build reads version %d: run \"day-night-cycle config migrate\"", v, ConfigVersion)
+	}
+	if v > ConfigVersion {
+		return fmt.Errorf("config is version %d, newer than this build reads (%d): upgrade day-night-cycle", v, ConfigVersion)
+	}
+	return nil
+}
+
+// Migrate upgrades a config document to ConfigVersion, keeping comments
+// and key order. It reports false when the config is already current.
+func Migrate(data []byte) ([]byte, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("parsing config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, false, fmt.Errorf("config is empty")
+	}
+	root := doc.Content[0]
+
+	v, err := configVersion(root)
+	if err != nil {
+		return nil, false, err
+	}
+	if v >= ConfigVersion {
+		return nil, false, nil
+	}
+
+	if l := lookup(root, "location"); l != nil {
+		remove(l, "name")
+	}
+
+	if p := lookup(root, "plugins"); p != nil && p.Kind == yaml.MappingNode {
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i := 0; i+1 < len(p.Content); i += 2 {
+			list.Content = append(list.Content, migrateEntry(p.Content[i], p.Content[i+1]))
+		}
+		*p = *list
+	}
+
+	setVersion(root)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, false, fmt.Errorf("encoding config: %w", err)
+	}
+	return buf.Bytes(), true, nil
+}
+
+// migrateEntry turns a version 1 "name: {fields}" pair into a list entry,
+// moving keys the entry doesn't understand under custom.
+func migrateEntry(name, fields *yaml.Node) *yaml.Node {
+	entry := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	entry.Content = append(entry.Content, scalar("name"), scalar(name.Value))
+
+	custom := lookup(fields, "custom")
+	var extra []*yaml.Node
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		key, value := fields.Content[i], fields.Content[i+1]
+		if entryKeys[key.Value] {
+			entry.Content = append(entry.Content, key, value)
+			continue
+		}
+		extra = append(extra, key, value)
+	}
+
+	if len(extra) > 0 {
+		if custom == nil {
+			custom = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			entry.Content = append(entry.Content, scalar("custom"), custom)
+		}
+		custom.Content = append(custom.Content, extra...)
+	}
+	return entry
+}
+
+// setVersion puts the version field first in the document, above any
+// comment that heads it.
+func setVersion(root *yaml.Node) {
+	remove(root, "version")
+	key := scalar("version")
+	if len(root.Content) > 0 {
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, {Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(ConfigVersion)}}, root.Content...)
+}
+
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func remove(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
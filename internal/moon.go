@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"math"
+	"time"
+)
+
+// moonriseAltitude is the moon's geocentric altitude at rise and set:
+// parallax lifts it while refraction and its radius are subtracted.
+const moonriseAltitude = 0.125
+
+// moonPosition returns the moon's ecliptic longitude and latitude, right
+// ascension and declination in degrees at time t. It uses the main terms
+// of the lunar theory, good to about a degree, which puts rise and set
+// within a few minutes.
+func moonPosition(t time.Time) (lon, lat, ra, dec float64) {
+	d := julianDay(t) - 2451545.0
+
+	meanLong := 218.316 + 13.176396*d
+	meanAnomaly := 134.963 + 13.064993*d
+	argLatitude := 93.272 + 13.229350*d
+
+	lon = math.Mod(meanLong+6.289*sinDeg(meanAnomaly), 360.0)
+	lat = 5.128 * sinDeg(argLatitude)
+
+	obliquity := 23.4397
+	ra = 180.0 * math.Atan2(sinDeg(lon)*cosDeg(obliquity)-math.Tan(math.Pi*lat/180.0)*sinDeg(obliquity), cosDeg(lon)) / math.Pi
+	dec = 180.0 * math.Asin(sinDeg(lat)*cosDeg(obliquity)+cosDeg(lat)*sinDeg(obliquity)*sinDeg(lon)) / math.Pi
+	return lon, lat, ra, dec
+}
+
+// MoonPhase returns how far through the lunar cycle the moon is at t: 0 is
+// new, 0.25 first quarter, 0.5 full, and 0.75 last quarter.
+func MoonPhase(t time.Time) float64 {
+	lon, _, _, _ := moonPosition(t)
+	sunLon := sunApparentLong(julianDayToJulianCentury(julianDay(t)))
+	elongation := math.Mod(lon-sunLon+720.0, 360.0)
+	return elongation / 360.0
+}
+
+// MoonIllumination returns the lit fraction of the moon's disc at t.
+func MoonIllumination(t time.Time) float64 {
+	lon, lat, _, _ := moonPosition(t)
+	sunLon := sunApparentLong(julianDayToJulianCentury(julianDay(t)))
+	return (1 - cosDeg(lat)*cosDeg(lon-sunLon)) / 2
+}
+
+// MoonPhaseName names a phase returned by MoonPhase.
+func MoonPhaseName(phase float64) string {
+	names := []string{
+		"new moon", "waxing crescent", "first quarter", "waxing gibbous",
+		"full moon", "waning gibbous", "last quarter", "waning crescent",
+	}
+	return names[int(math.Round(phase*8))%8]
+}
+
+// MoonTimes returns moonrise and moonset on t's local date. Either is zero
+// when the moon doesn't rise or set that day, which happens about once a
+// month.
+func MoonTimes(lat, lon float64, t time.Time) (rise, set time.Time) {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	end := start.AddDate(0, 0, 1)
+
+	// Step through the day and interpolate where the altitude crosses the
+	// horizon.
+	const step = 10 * time.Minute
+	prev := moonAltitude(lat, lon, start) - moonriseAltitude
+	for at := start.Add(step); !at.After(end); at = at.Add(step) {
+		alt := moonAltitude(lat, lon, at) - moonriseAltitude
+		if (prev < 0) != (alt < 0) {
+			crossing := at.Add(-time.Duration(float64(step) * alt / (alt - prev)))
+			if alt > 0 && rise.IsZero() {
+				rise = crossing
+			}
+			if alt < 0 && set.IsZero() {
+				set = crossing
+			}
+		}
+		prev = alt
+	}
+	return rise, set
+}
+
+// moonAltitude returns the moon's geocentric altitude in degrees.
+func moonAltitude(lat, lon float64, t time.Time) float64 {
+	_, _, ra, dec := moonPosition(t)
+	siderealTime := 280.16 + 360.9856235*(julianDay(t)-2451545.0) + lon
+	hourAngle := siderealTime - ra
+	return 180.0 * math.Asin(sinDeg(lat)*sinDeg(dec)+cosDeg(lat)*cosDeg(dec)*cosDeg(hourAngle)) / math.Pi
+}
+
+func sinDeg(x float64) float64 { return math.Sin(math.Pi * x / 180.0) }
+func cosDeg(x float64) float64 { return math.Cos(math.Pi * x / 180.0) }
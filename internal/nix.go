@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const homeManagerTemplate = `# Generated by "day-night-cycle schedule --format home-manager" on {{.Date}}.
+# The times are that day's; regenerate the module now and then to follow
+# the season.
+{ config, lib, pkgs, ... }:
+
+let
+  # Point at your package's binary if Nix builds day-night-cycle.
+  dnc = {{nix .BinaryPath}};
+  configFile = {{.ConfigFile}};
+  logs = {{.LogDir}};
+in
+{
+{{- if .HomeFile}}
+  home.file.{{nix .HomeFile}}.text = ''
+{{.Config}}  '';
+{{end}}
+  launchd.agents.day-night-cycle = lib.mkIf pkgs.stdenv.isDarwin {
+    enable = true;
+    config = {
+      ProgramArguments = [ dnc "--config" configFile "auto" "--scheduled" ];
+      RunAtLoad = true;
+      StartCalendarInterval = [
+{{- range .Times}}
+        { Hour = {{.Hour}}; Minute = {{.Minute}}; }
+{{- end}}
+      ];
+{{- if .RetryInterval}}
+      StartInterval = {{.RetryInterval}};
+{{- end}}
+      StandardOutPath = "${logs}/schedule.log";
+      StandardErrorPath = "${logs}/schedule.error.log";
+    };
+  };
+
+  # launchd doesn't create the log directory.
+  home.activation.dayNightCycleLogs = lib.mkIf pkgs.stdenv.isDarwin
+    (lib.hm.dag.entryAfter [ "writeBoundary" ] ''
+      run mkdir -p ${lib.escapeShellArg logs}
+    '');
+
+  systemd.user.services.day-night-cycle = lib.mkIf pkgs.stdenv.isLinux {
+    Unit.Description = "Switch themes between day and night";
+    Service = {
+      Type = "oneshot";
+      ExecStart = lib.escapeShellArgs [ dnc "--config" configFile "auto" "--scheduled" ];
+    };
+  };
+
+  systemd.user.timers.day-night-cycle = lib.mkIf pkgs.stdenv.isLinux {
+    Unit.Description = "Switch themes at sunrise and sunset";
+    Timer = {
+      OnCalendar = [
+{{- range .Times}}
+        "*-*-* {{.Format "15:04"}}:00"
+{{- end}}
+      ];
+      Persistent = true;
+      # Like RunAtLoad, catch up at login.
+      OnStartupSec = "30s";
+{{- if .RetryInterval}}
+      OnUnitActiveSec = "{{.RetryInterval}}s";
+{{- end}}
+    };
+    Install.WantedBy = [ "timers.target" ];
+  };
+}
+`
+
+// HomeManagerModule returns a home-manager module that runs auto at day's
+// times every day, as a launchd agent on macOS and a systemd user timer on
+// Linux, for setups that declare their services instead of letting
+// "schedule install" write them. A config under the home directory is
+// embedded in the module too. Unlike the launchd schedule, nothing
+// refreshes the times.
+func HomeManagerModule(configPath string, day ScheduleDay, retry bool) (string, error) {
+	binaryPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("getting executable path: %w", err)
+	}
+	binaryPath, err = filepath.EvalSymlinks(binaryPath)
+	if err != nil {
+		return "", fmt.Errorf("resolving symlinks: %w", err)
+	}
+
+	absConfigPath, err := filepath.Abs(configPath)
+	if err != nil {
+		absConfigPath = configPath
+	}
+	configData, err := os.ReadFile(absConfigPath)
+	if err != nil {
+		return "", fmt.Errorf("reading config: %w", err)
+	}
+
+	// The timers fire on the machine's clock, one entry per minute.
+	var times []time.Time
+	for _, t := range append([]time.Time{day.Sunrise, day.Sunset}, day.Plugins...) {
+		t = t.Local()
+		if !slices.ContainsFunc(times, func(u time.Time) bool { return u.Format("15:04") == t.Format("15:04") }) {
+			times = append(times, t)
+		}
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return strings.Compare(a.Format("15:04"), b.Format("15:04")) })
+
+	retryInterval := 0
+	if retry {
+		retryInterval = 900
+	}
+
+	data := map[string]interface{}{
+		"Date":          day.Sunrise.Format("January 2, 2006"),
+		"BinaryPath":    binaryPath,
+		"ConfigFile":    nixString(absConfigPath),
+		"LogDir":        nixString(LogDir(absConfigPath)),
+		"Times":         times,
+		"RetryInterval": retryInterval,
+	}
+	// Paths under the home directory follow it, and the config there is
+	// managed by home-manager.
+	home, _ := os.UserHomeDir()
+	if rel, err := filepath.Rel(home, absConfigPath); home != "" && err == nil && !strings.HasPrefix(rel, "..") {
+		data["HomeFile"] = rel
+		data["Config"] = nixIndented(string(configData), "    ")
+		data["ConfigFile"] = `"${config.home.homeDirectory}/` + nixEscape(rel) + `"`
+		data["LogDir"] = `"${config.home.homeDirectory}/` + nixEscape(filepath.Join(filepath.Dir(rel), "logs")) + `"`
+	}
+
+	tmpl, err := template.New("home-manager").Funcs(template.FuncMap{"nix": nixString}).Parse(homeManagerTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parsing template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("writing module: %w", err)
+	}
+	return b.String(), nil
+}
+
+// nixString quotes s as a Nix string.
+func nixString(s string) string {
+	return `"` + nixEscape(s) + `"`
+}
+
+func nixEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`).Replace(s)
+}
+
+// nixIndented returns s as the body of a Nix indented string, each line
+// prefixed by indent, which Nix strips again.
+func nixIndented(s, indent string) string {
+	s = strings.NewReplacer("''", "'''", "${", "''${").Replace(s)
+	var b strings.Builder
+	for line := range strings.Lines(s) {
+		if strings.TrimSpace(line) != "" {
+			b.WriteString(indent)
+		}
+		b.WriteString(line)
+	}
+	if !strings.HasSuffix(s, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// OnBattery reports whether the machine is running on battery: pmset's
+// power source on macOS, the mains supplies in /sys/class/power_supply on
+// Linux. Machines without a battery, or where it can't be told, count as
+// on AC power.
+func OnBattery() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		output, err := exec.Command("pmset", "-g", "ps").Output()
+		return err == nil && strings.Contains(string(output), "'Battery Power'")
+	case "linux":
+		types, _ := filepath.Glob("/sys/class/power_supply/*/type")
+		mains := false
+		for _, path := range types {
+			kind, err := os.ReadFile(path)
+			if err != nil || strings.TrimSpace(string(kind)) != "Mains" {
+				continue
+			}
+			mains = true
+			online, err := os.ReadFile(filepath.Join(filepath.Dir(path), "online"))
+			if err == nil && strings.TrimSpace(string(online)) == "1" {
+				return false
+			}
+		}
+		return mains
+	}
+	return false
+}
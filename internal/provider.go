@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A SunProvider returns sunrise and sunset on the day of t at a location.
+type SunProvider interface {
+	Times(lat, lon float64, t time.Time) (sunrise, sunset time.Time, err error)
+}
+
+// SunTableDay is one row of a fixed sunrise/sunset table. Date is "MM-DD"
+// and times are "15:04" in the location's time zone.
+type SunTableDay struct {
+	Date    string `yaml:"date"`
+	Sunrise string `yaml:"sunrise"`
+	Sunset  string `yaml:"sunset"`
+}
+
+// newProvider returns the provider named in the location config, or nil
+// for the built-in NOAA calculation.
+func newProvider(lc LocationConfig, cache Cache) (SunProvider, error) {
+	switch lc.Provider {
+	case "", "noaa":
+		return nil, nil
+	case "sunrise-sunset":
+		return apiProvider{cache: cache}, nil
+	case "table":
+		return newTableProvider(lc.Table)
+	}
+	return nil, fmt.Errorf("invalid provider %q: want noaa, sunrise-sunset, or table", lc.Provider)
+}
+
+// apiProvider fetches times from sunrise-sunset.org, e.g. to cross-check
+// the built-in calculation.
+type apiProvider struct {
+	cache Cache
+}
+
+func (p apiProvider) Times(lat, lon float64, t time.Time) (sunrise, sunset time.Time, err error) {
+	url := fmt.Sprintf("https://api.sunrise-sunset.org/json?lat=%f&lng=%f&date=%s&formatted=0",
+		lat, lon, t.Format("2006-01-02"))
+
+	// A date's times don't change, so a cached response stays good.
+	body, err := p.cache.Get(url, 30*24*time.Hour)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	var resp struct {
+		Status  string `json:"status"`
+		Results struct {
+			Sunrise time.Time `json:"sunrise"`
+			Sunset  time.Time `json:"sunset"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing sunrise-sunset.org response: %w", err)
+	}
+	if resp.Status != "OK" {
+		return time.Time{}, time.Time{}, fmt.Errorf("sunrise-sunset.org: %s", resp.Status)
+	}
+	return resp.Results.Sunrise.In(t.Location()), resp.Results.Sunset.In(t.Location()), nil
+}
+
+// tableProvider looks times up in a fixed table, using the latest row on
+// or before the date, so a row per week or month is enough.
+type tableProvider []tableDay
+
+type tableDay struct {
+	date            string
+	sunrise, sunset time.Duration
+}
+
+func newTableProvider(rows []SunTableDay) (tableProvider, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("provider table needs a table")
+	}
+
+	var table tableProvider
+	for _, row := range rows {
+		if _, err := time.Parse("01-02", row.Date); err != nil {
+			return nil, fmt.Errorf("invalid table date %q: want MM-DD", row.Date)
+		}
+		sunrise, err := clockDuration(row.Sunrise)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sunrise for %s: %w", row.Date, err)
+		}
+		sunset, err := clockDuration(row.Sunset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sunset for %s: %w", row.Date, err)
+		}
+		table = append(table, tableDay{date: row.Date, sunrise: sunrise, sunset: sunset})
+	}
+
+	slices.SortFunc(table, func(a, b tableDay) int {
+		return strings.Compare(a.date, b.date)
+	})
+	return table, nil
+}
+
+func (p tableProvider) Times(lat, lon float64, t time.Time) (sunrise, sunset time.Time, err error) {
+	date := t.Format("01-02")
+
+	// Before the first row, the year's last row still applies.
+	row := p[len(p)-1]
+	for _, day := range p {
+		if day.date <= date {
+			row = day
+		}
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return midnight.Add(row.sunrise), midnight.Add(row.sunset), nil
+}
+
+// clockDuration parses "15:04" as the time since midnight.
+func clockDuration(s string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q: want HH:MM", s)
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
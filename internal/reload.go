@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Reload makes an app pick up changes right after its plugin runs, for apps
+// that only read their settings at launch. Each field is optional and they
+// run in order: Command, Signal, Relaunch.
+type Reload struct {
+	Command  string `yaml:"command,omitempty"`  // shell command to run
+	Signal   string `yaml:"signal,omitempty"`   // signal name such as "USR1", sent to Process
+	Process  string `yaml:"process,omitempty"`  // exact process name for Signal
+	Relaunch string `yaml:"relaunch,omitempty"` // macOS app name to quit and reopen
+}
+
+// signals are the signal names Reload accepts, without the SIG prefix.
+var signals = []string{"HUP", "INT", "QUIT", "KILL", "USR1", "USR2", "ALRM", "TERM", "CONT", "STOP", "TSTP", "WINCH"}
+
+// validate checks that a signal names a known signal and a process to send
+// it to.
+func (r Reload) validate() error {
+	if r.Signal == "" {
+		return nil
+	}
+	signal := strings.TrimPrefix(strings.ToUpper(r.Signal), "SIG")
+	if !slices.Contains(signals, signal) {
+		return fmt.Errorf("unknown signal %q: want one of %s", r.Signal, strings.Join(signals, ", "))
+	}
+	if r.Process == "" {
+		return fmt.Errorf("signal %s needs a process to send it to", r.Signal)
+	}
+	return nil
+}
+
+// Run performs the configured reload steps.
+func (r Reload) Run() error {
+	if r.Command != "" {
+		if output, err := exec.Command("sh", "-c", r.Command).CombinedOutput(); err != nil {
+			return fmt.Errorf("reload command failed: %w: %s", err, output)
+		}
+	}
+
+	if r.Signal != "" {
+		signal := strings.TrimPrefix(strings.ToUpper(r.Signal), "SIG")
+		// pkill exits 1 when nothing matched; an app that isn't running
+		// has nothing to reload.
+		err := exec.Command("pkill", "-"+signal, "-x", r.Process).Run()
+		if exitErr, ok := err.(*exec.ExitError); err != nil && (!ok || exitErr.ExitCode() != 1) {
+			return fmt.Errorf("sending SIG%s to %s: %w", signal, r.Process, err)
+		}
+	}
+
+	if r.Relaunch != "" {
+		if exec.Command("pgrep", "-x", r.Relaunch).Run() != nil {
+			return nil
+		}
+
+		quit := fmt.Sprintf(`tell application "%s" to quit`, r.Relaunch)
+		if output, err := exec.Command("osascript", "-e", quit).CombinedOutput(); err != nil {
+			return fmt.Errorf("quitting %s: %w: %s", r.Relaunch, err, output)
+		}
+
+		// Reopening while the old instance is still shutting down just
+		// reactivates it.
+		for i := 0; i < 20 && exec.Command("pgrep", "-x", r.Relaunch).Run() == nil; i++ {
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		if output, err := exec.Command("open", "-a", r.Relaunch).CombinedOutput(); err != nil {
+			return fmt.Errorf("reopening %s: %w: %s", r.Relaunch, err, output)
+		}
+	}
+
+	return nil
+}
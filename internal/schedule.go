@@ -3,7 +3,9 @@ package internal
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"text/template"
 	"time"
 )
@@ -20,22 +22,29 @@ const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 		<string>--config</string>
 		<string>{{.ConfigPath}}</string>
 		<string>auto</string>
+		<string>--scheduled</string>
 	</array>
+	<key>RunAtLoad</key>
+	<true/>
 	<key>StartCalendarInterval</key>
 	<array>
+{{- range .Times}}
 		<dict>
+			<key>Month</key>
+			<integer>{{printf "%d" .Month}}</integer>
+			<key>Day</key>
+			<integer>{{.Day}}</integer>
 			<key>Hour</key>
-			<integer>{{.SunriseHour}}</integer>
+			<integer>{{.Hour}}</integer>
 			<key>Minute</key>
-			<integer>{{.SunriseMinute}}</integer>
-		</dict>
-		<dict>
-			<key>Hour</key>
-			<integer>{{.SunsetHour}}</integer>
-			<key>Minute</key>
-			<integer>{{.SunsetMinute}}</integer>
+			<integer>{{.Minute}}</integer>
 		</dict>
+{{- end}}
 	</array>
+{{- if .RetryInterval}}
+	<key>StartInterval</key>
+	<integer>{{.RetryInterval}}</integer>
+{{- end}}
 	<key>StandardOutPath</key>
 	<string>{{.LogPath}}/schedule.log</string>
 	<key>StandardErrorPath</key>
@@ -43,8 +52,99 @@ const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
 </dict>
 </plist>`
 
-// Generate creates a launchd plist file for automatic scheduling.
-func Generate(configPath string, sunrise, sunset time.Time) error {
+// refreshTemplate reruns "schedule" shortly after midnight, since the
+// schedule only holds one day's sunrise and sunset minutes.
+const refreshTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.daynightcycle.refresh</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{.BinaryPath}}</string>
+		<string>--config</string>
+		<string>{{.ConfigPath}}</string>
+		<string>schedule</string>
+	</array>
+	<key>StartCalendarInterval</key>
+	<dict>
+		<key>Hour</key>
+		<integer>0</integer>
+		<key>Minute</key>
+		<integer>5</integer>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>{{.LogPath}}/refresh.log</string>
+	<key>StandardErrorPath</key>
+	<string>{{.LogPath}}/refresh.error.log</string>
+</dict>
+</plist>`
+
+// Launchd labels of the schedule job and its daily refresh job.
+const (
+	ScheduleAgent = "com.daynightcycle.schedule"
+	RefreshAgent  = "com.daynightcycle.refresh"
+)
+
+// AgentPath returns the plist path for a launchd label.
+func AgentPath(label string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library/LaunchAgents", label+".plist")
+}
+
+// AgentLoaded reports whether launchd has the job loaded.
+func AgentLoaded(label string) bool {
+	return exec.Command("launchctl", "list", label).Run() == nil
+}
+
+// LoadAgent loads a job's plist, replacing it if it is already loaded.
+func LoadAgent(label string) error {
+	path := AgentPath(label)
+	_ = exec.Command("launchctl", "unload", path).Run()
+	if output, err := exec.Command("launchctl", "load", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("loading %s: %w: %s", label, err, output)
+	}
+	return nil
+}
+
+// UnloadAgent unloads a job and removes its plist.
+func UnloadAgent(label string) error {
+	path := AgentPath(label)
+	_ = exec.Command("launchctl", "unload", path).Run()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing %s: %w", path, err)
+	}
+	return nil
+}
+
+// LogDir returns the directory the launchd jobs log to, next to the config.
+func LogDir(configPath string) string {
+	absConfigPath, err := filepath.Abs(configPath)
+	if err != nil {
+		absConfigPath = configPath
+	}
+	return filepath.Join(filepath.Dir(absConfigPath), "logs")
+}
+
+// ScheduleDay holds one day's transition times.
+type ScheduleDay struct {
+	Sunrise time.Time
+	Sunset  time.Time
+	Plugins []time.Time // Per-plugin offset and dusk times
+}
+
+// Generate creates a launchd plist file for automatic scheduling. The job
+// runs at sunrise, sunset, and each plugin time of every day given, with
+// explicit dates so the schedule stays right if a daily refresh is missed.
+// With retry, it also runs every 15 minutes to apply plugins deferred until
+// their app quits. It runs at load too, so a login or reboot catches up.
+// Every run is "auto --scheduled", which only re-applies everything when a
+// scheduled time has passed since the last run, so a load or retry keeps a
+// forced mode. A second job regenerates the schedule daily so its times
+// follow the season; if the schedule job is already loaded, it is reloaded
+// to pick up the new times.
+func Generate(configPath string, days []ScheduleDay, retry bool) error {
 	binaryPath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("getting executable path: %w", err)
@@ -61,9 +161,10 @@ func Generate(configPath string, sunrise, sunset time.Time) error {
 	}
 
 	home, _ := os.UserHomeDir()
-	launchdDir := filepath.Join(home, "Library/LaunchAgents")
-	plistPath := filepath.Join(launchdDir, "com.daynightcycle.schedule.plist")
-	logPath := filepath.Join(filepath.Dir(absConfigPath), "logs")
+	plistPath := AgentPath(ScheduleAgent)
+	refreshPath := AgentPath(RefreshAgent)
+	launchdDir := filepath.Dir(plistPath)
+	logPath := LogDir(absConfigPath)
 
 	if err := os.MkdirAll(launchdDir, 0755); err != nil {
 		return fmt.Errorf("creating LaunchAgents directory: %w", err)
@@ -73,29 +174,50 @@ func Generate(configPath string, sunrise, sunset time.Time) error {
 		return fmt.Errorf("creating logs directory: %w", err)
 	}
 
+	// launchd fires on wall-clock minutes, so times in the same minute
+	// collapse into one entry.
+	var times []time.Time
+	var extra []string
+	seen := map[string]bool{}
+	for i, day := range days {
+		for j, t := range append([]time.Time{day.Sunrise, day.Sunset}, day.Plugins...) {
+			key := t.Format("01-02 15:04")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			times = append(times, t)
+			if i == 0 && j >= 2 {
+				extra = append(extra, FormatTime(t, "3:04 PM"))
+			}
+		}
+	}
+
+	retryInterval := 0
+	if retry {
+		retryInterval = 900
+	}
+
 	data := map[string]interface{}{
 		"BinaryPath":    binaryPath,
 		"ConfigPath":    absConfigPath,
-		"SunriseHour":   sunrise.Hour(),
-		"SunriseMinute": sunrise.Minute(),
-		"SunsetHour":    sunset.Hour(),
-		"SunsetMinute":  sunset.Minute(),
+		"Times":         times,
+		"RetryInterval": retryInterval,
 		"LogPath":       logPath,
 	}
 
-	tmpl, err := template.New("plist").Parse(plistTemplate)
-	if err != nil {
-		return fmt.Errorf("parsing template: %w", err)
+	if err := writePlist(plistPath, plistTemplate, data); err != nil {
+		return err
 	}
-
-	f, err := os.Create(plistPath)
-	if err != nil {
-		return fmt.Errorf("creating plist file: %w", err)
+	if err := writePlist(refreshPath, refreshTemplate, data); err != nil {
+		return err
 	}
-	defer f.Close()
 
-	if err := tmpl.Execute(f, data); err != nil {
-		return fmt.Errorf("writing plist: %w", err)
+	// launchd only reads a plist when it is loaded.
+	if AgentLoaded(ScheduleAgent) {
+		if err := LoadAgent(ScheduleAgent); err != nil {
+			return err
+		}
 	}
 
 	displayPlistPath := plistPath
@@ -107,13 +229,36 @@ func Generate(configPath string, sunrise, sunset time.Time) error {
 		displayLogPath = filepath.Join("~", logPath[len(home):])
 	}
 
-	fmt.Printf("\nLaunchd schedule created successfully\n")
-	fmt.Printf("\nSchedule for %s:\n", time.Now().Format("Monday, January 2, 2006"))
-	fmt.Printf("  Sunrise: %s\n", sunrise.Format("3:04 PM"))
-	fmt.Printf("  Sunset:  %s\n", sunset.Format("3:04 PM"))
-	fmt.Printf("\nPlist file: %s\n", displayPlistPath)
-	fmt.Printf("Logs directory: %s\n", displayLogPath)
+	fmt.Print(T("\nLaunchd schedule created successfully\n"))
+	fmt.Printf(T("\nSchedule for %s:\n"), FormatTime(time.Now(), "Monday, January 2, 2006"))
+	fmt.Printf(T("  Sunrise: %s\n"), FormatTime(days[0].Sunrise, "3:04 PM"))
+	fmt.Printf(T("  Sunset:  %s\n"), FormatTime(days[0].Sunset, "3:04 PM"))
+	if len(extra) > 0 {
+		fmt.Printf(T("  Plugins: %s\n"), strings.Join(extra, ", "))
+	}
+	fmt.Printf(T("  Covers:  %d days, through %s\n"), len(days), FormatTime(days[len(days)-1].Sunset, "Monday, January 2"))
+	fmt.Printf(T("\nPlist file: %s\n"), displayPlistPath)
+	fmt.Printf(T("Daily refresh: %s\n"), filepath.Join(filepath.Dir(displayPlistPath), filepath.Base(refreshPath)))
+	fmt.Printf(T("Logs directory: %s\n"), displayLogPath)
 	fmt.Println()
 
 	return nil
 }
+
+func writePlist(path, text string, data map[string]interface{}) error {
+	tmpl, err := template.New("plist").Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing template: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating plist file: %w", err)
+	}
+	defer f.Close()
+
+	if err := tmpl.Execute(f, data); err != nil {
+		return fmt.Errorf("writing plist: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ResolveSecrets returns a copy of a plugin's custom settings with every
+// {secretRef: name} value replaced by the secret stored under name in the
+// OS credential store, so tokens stay out of the config file. Secrets are
+// read just before a plugin runs, never at load time.
+func ResolveSecrets(custom map[string]any) (map[string]any, error) {
+	resolved, err := resolveValue(custom)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := resolved.(map[string]any)
+	return m, nil
+}
+
+func resolveValue(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		if ref, ok := v["secretRef"].(string); ok && len(v) == 1 {
+			return lookupSecret(ref)
+		}
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			r, err := resolveValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			r, err := resolveValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// lookupSecret reads a secret from the macOS Keychain (generic password
+// with service name), libsecret (attribute service=name), or the Windows
+// Credential Manager (target name, via the CredentialManager PowerShell
+// module).
+func lookupSecret(name string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", name, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", name)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command",
+			fmt.Sprintf("(Get-StoredCredential -Target '%s').GetNetworkCredential().Password", strings.ReplaceAll(name, "'", "''")))
+	default:
+		return "", fmt.Errorf("secrets are not supported on %s", runtime.GOOS)
+	}
+
+	output, err := cmd.Output()
+	secret := strings.TrimRight(string(output), "\r\n")
+	if err != nil || secret == "" {
+		return "", fmt.Errorf("secret %q not found in the credential store", name)
+	}
+	return secret, nil
+}
@@ -14,6 +14,12 @@ const (
 
 // CalculateTimes returns sunrise and sunset times for a given location and date.
 func CalculateTimes(lat, lon float64, t time.Time) (sunrise, sunset time.Time) {
+	return CalculateTimesAt(lat, lon, t, sunriseZenith)
+}
+
+// CalculateTimesAt returns the morning and evening times the sun crosses the
+// given zenith angle, e.g. 96 for the start and end of civil twilight.
+func CalculateTimesAt(lat, lon float64, t time.Time, zenith float64) (sunrise, sunset time.Time) {
 	date := t
 
 	// Calculate Julian Day
@@ -21,15 +27,15 @@ func CalculateTimes(lat, lon float64, t time.Time) (sunrise, sunset time.Time) {
 
 	// Iterative calculation for more accuracy
 	// First pass: rough estimate
-	sunriseMinutes := timeOfTransit(jd, lat, lon, sunriseZenith, true)
-	sunsetMinutes := timeOfTransit(jd, lat, lon, sunriseZenith, false)
+	sunriseMinutes := timeOfTransit(jd, lat, lon, zenith, true)
+	sunsetMinutes := timeOfTransit(jd, lat, lon, zenith, false)
 
 	// Second pass: refined calculation using the rough estimate
 	sunriseJD := jd + sunriseMinutes/1440.0
 	sunsetJD := jd + sunsetMinutes/1440.0
 
-	sunriseMinutes = timeOfTransit(sunriseJD, lat, lon, sunriseZenith, true)
-	sunsetMinutes = timeOfTransit(sunsetJD, lat, lon, sunriseZenith, false)
+	sunriseMinutes = timeOfTransit(sunriseJD, lat, lon, zenith, true)
+	sunsetMinutes = timeOfTransit(sunsetJD, lat, lon, zenith, false)
 
 	// Convert minutes since midnight UTC to time
 	sunrise = minutesToTime(date, sunriseMinutes)
@@ -38,6 +44,97 @@ func CalculateTimes(lat, lon float64, t time.Time) (sunrise, sunset time.Time) {
 	return sunrise, sunset
 }
 
+// SolarNoon returns when the sun is highest on t's date.
+func SolarNoon(lat, lon float64, t time.Time) time.Time {
+	jd := julianDay(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+
+	// Refine once using the equation of time at the rough estimate.
+	minutes := 720.0 - 4.0*lon - equationOfTime(julianDayToJulianCentury(jd))
+	minutes = 720.0 - 4.0*lon - equationOfTime(julianDayToJulianCentury(jd+minutes/1440.0))
+
+	return minutesToTime(t, minutes)
+}
+
+// DayLength returns the time between sunrise and sunset on t's date: zero
+// during polar night and 24 hours during polar day.
+func DayLength(lat, lon float64, t time.Time) time.Duration {
+	// Sunrise and sunset both land on solar noon when the sun never
+	// crosses the horizon, so tell the two cases apart by the sun at noon.
+	jc := julianDayToJulianCentury(julianDay(SolarNoon(lat, lon, t)))
+	switch h := cosHourAngle(lat, sunDeclination(jc), sunriseZenith); {
+	case h < -1:
+		return 24 * time.Hour
+	case h > 1:
+		return 0
+	}
+	sunrise, sunset := CalculateTimes(lat, lon, t)
+	return sunset.Sub(sunrise)
+}
+
+// NextSeason returns the first equinox or solstice after t and its name,
+// e.g. "June solstice".
+func NextSeason(t time.Time) (time.Time, string) {
+	longitude := func(t time.Time) float64 {
+		return math.Mod(sunApparentLong(julianDayToJulianCentury(julianDay(t)))+360.0, 360.0)
+	}
+
+	quarter := math.Floor(longitude(t)/90.0) + 1
+	target := math.Mod(quarter*90.0, 360.0)
+
+	// The sun moves about a degree a day, so the target is within 100 days.
+	// Bisect on how far past the target the sun is.
+	past := func(t time.Time) bool {
+		return math.Mod(longitude(t)-target+540.0, 360.0)-180.0 >= 0
+	}
+	lo, hi := t, t.AddDate(0, 0, 100)
+	for hi.Sub(lo) > time.Minute {
+		mid := lo.Add(hi.Sub(lo) / 2)
+		if past(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	names := map[float64]string{
+		0:   "March equinox",
+		90:  "June solstice",
+		180: "September equinox",
+		270: "December solstice",
+	}
+	return hi, names[target]
+}
+
+// Elevation returns the sun's geometric elevation above the horizon in
+// degrees at time t; negative values are below the horizon.
+func Elevation(lat, lon float64, t time.Time) float64 {
+	elevation, _ := Position(lat, lon, t)
+	return elevation
+}
+
+// Position returns the sun's geometric elevation and its azimuth in degrees
+// clockwise from north at time t.
+func Position(lat, lon float64, t time.Time) (elevation, azimuth float64) {
+	jc := julianDayToJulianCentury(julianDay(t))
+	declination := sunDeclination(jc)
+
+	utc := t.UTC()
+	minutes := float64(utc.Hour()*60+utc.Minute()) + float64(utc.Second())/60.0
+	trueSolarTime := minutes + equationOfTime(jc) + 4.0*lon
+	hourAngle := trueSolarTime/4.0 - 180.0
+
+	latRad := math.Pi * lat / 180.0
+	decRad := math.Pi * declination / 180.0
+	haRad := math.Pi * hourAngle / 180.0
+
+	sinElevation := math.Sin(latRad)*math.Sin(decRad) +
+		math.Cos(latRad)*math.Cos(decRad)*math.Cos(haRad)
+
+	elevation = 180.0 * math.Asin(sinElevation) / math.Pi
+	azimuth = 180.0*math.Atan2(math.Sin(haRad), math.Cos(haRad)*math.Sin(latRad)-math.Tan(decRad)*math.Cos(latRad))/math.Pi + 180.0
+	return elevation, azimuth
+}
+
 // timeOfTransit calculates the time of sun transit for a given zenith angle.
 // Returns minutes since midnight UTC.
 func timeOfTransit(jd, lat, lon, zenith float64, rising bool) float64 {
@@ -188,12 +285,7 @@ func equationOfTime(jc float64) float64 {
 
 // hourAngleFromZenith calculates the hour angle for a given zenith.
 func hourAngleFromZenith(lat, declination, zenith float64) float64 {
-	latRad := math.Pi * lat / 180.0
-	decRad := math.Pi * declination / 180.0
-	zenithRad := math.Pi * zenith / 180.0
-
-	h := (math.Cos(zenithRad) - math.Sin(latRad)*math.Sin(decRad)) /
-		(math.Cos(latRad) * math.Cos(decRad))
+	h := cosHourAngle(lat, declination, zenith)
 
 	// Handle polar day/night (sun never rises/sets)
 	if h > 1.0 {
@@ -206,6 +298,18 @@ func hourAngleFromZenith(lat, declination, zenith float64) float64 {
 	return 180.0 * math.Acos(h) / math.Pi
 }
 
+// cosHourAngle returns the cosine of the hour angle at which the sun
+// crosses the zenith; it is below -1 when the sun never sets that day and
+// above 1 when it never rises.
+func cosHourAngle(lat, declination, zenith float64) float64 {
+	latRad := math.Pi * lat / 180.0
+	decRad := math.Pi * declination / 180.0
+	zenithRad := math.Pi * zenith / 180.0
+
+	return (math.Cos(zenithRad) - math.Sin(latRad)*math.Sin(decRad)) /
+		(math.Cos(latRad) * math.Cos(decRad))
+}
+
 // minutesToTime converts minutes since midnight UTC to local time.
 func minutesToTime(date time.Time, minutes float64) time.Time {
 	hours := int(minutes / 60.0)
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State records what the tool last did, persisted between invocations.
+type State struct {
+	Mode    string    `json:"mode"`
+	Applied time.Time `json:"applied"`
+	Profile string    `json:"profile,omitempty"`
+
+	// Forced is set when Mode came from light, dark, or toggle; the
+	// schedule leaves it until the next sunrise or sunset.
+	Forced bool `json:"forced,omitempty"`
+	// Held is set while a transition waits for screen sharing to end.
+	Held bool `json:"held,omitempty"`
+	// IncreasedContrast is set while macos-system has Increase Contrast on,
+	// so it is turned back off when accessibility mode is.
+	IncreasedContrast bool `json:"increasedContrast,omitempty"`
+
+	// Deferred lists plugin labels waiting for an app to quit.
+	Deferred []string `json:"deferred,omitempty"`
+	// Failed lists plugin labels that failed in the last run.
+	Failed []string `json:"failed,omitempty"`
+
+	// Snoozed is a transition delayed by "snooze" until SnoozedUntil.
+	Snoozed      time.Time `json:"snoozed,omitzero"`
+	SnoozedUntil time.Time `json:"snoozedUntil,omitzero"`
+}
+
+// StatePath returns the state file path, kept next to the config file.
+func StatePath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "state.json")
+}
+
+// SocketPath returns the daemon's control socket path, next to the config file.
+func SocketPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "daemon.sock")
+}
+
+// BackupDir returns where app files are backed up before their first
+// change, next to the config file.
+func BackupDir(configPath string) string {
+	absConfigPath, err := filepath.Abs(configPath)
+	if err != nil {
+		absConfigPath = configPath
+	}
+	return filepath.Join(filepath.Dir(absConfigPath), "backups")
+}
+
+// LoadState reads the state file. A missing file yields the zero State.
+func LoadState(path string) (State, error) {
+	var s State
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("reading state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("parsing state: %w", err)
+	}
+	return s, nil
+}
+
+// SaveState writes the state file.
+func SaveState(path string, s State) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+	return nil
+}
+
+// PluginDir returns where "plugin install" puts external plugins, next to
+// the config file.
+func PluginDir(configPath string) string {
+	absConfigPath, err := filepath.Abs(configPath)
+	if err != nil {
+		absConfigPath = configPath
+	}
+	return filepath.Join(filepath.Dir(absConfigPath), "plugins")
+}
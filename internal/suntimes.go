@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sunTimes caches each day's sunrise and sunset, so frequent runs like a
+// status bar polling every minute skip the calculation or API call and
+// agree with each other all day. Keys hold the date and every setting
+// that changes the times.
+type sunTimes map[string]cachedTimes
+
+type cachedTimes struct {
+	Date    string    `json:"date"`
+	Sunrise time.Time `json:"sunrise"`
+	Sunset  time.Time `json:"sunset"`
+}
+
+// sunTimesKeep is how many days back cached times are kept, and
+// sunTimesAhead how many days ahead. Commands like "sun --year" look up
+// months ahead, which would otherwise fill the cache with days it won't
+// need for a long time.
+const (
+	sunTimesKeep  = 7
+	sunTimesAhead = 7
+)
+
+func sunTimesPath() string {
+	return filepath.Join(CacheDir(), "suntimes.json")
+}
+
+// loadSunTimes reads the cache. A missing or unreadable cache is empty.
+func loadSunTimes() sunTimes {
+	times := sunTimes{}
+	if data, err := os.ReadFile(sunTimesPath()); err == nil {
+		_ = json.Unmarshal(data, &times)
+	}
+	return times
+}
+
+// cacheable reports whether date, as 2006-01-02, is from sunTimesKeep
+// days back to sunTimesAhead days ahead, the days the cache keeps.
+func cacheable(date string) bool {
+	now := time.Now()
+	return date >= now.AddDate(0, 0, -sunTimesKeep).Format("2006-01-02") &&
+		date <= now.AddDate(0, 0, sunTimesAhead).Format("2006-01-02")
+}
+
+// save writes the cache, keeping only cacheable days. It writes a
+// temporary file and renames it over the cache, so concurrent runs never
+// read a partial one.
+func (s sunTimes) save() error {
+	for key, times := range s {
+		if !cacheable(times.Date) {
+			delete(s, key)
+		}
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(CacheDir(), 0755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(CacheDir(), "suntimes-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), sunTimesPath())
+}
+
+// key identifies the times for t's date under the location's settings.
+func (lc LocationConfig) key(t time.Time) string {
+	return fmt.Sprintf("%s %v %v %s %v %v", t.Format("2006-01-02"),
+		lc.Latitude, lc.Longitude, lc.Provider, lc.Horizon, lc.Table)
+}
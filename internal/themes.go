@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+)
+
+// ThemePack maps plugin names to the values a palette uses for them, so
+// entries can take them all from one theme name.
+type ThemePack map[string]ThemeValues
+
+// ThemeValues are a plugin entry's day, night, and dusk values and custom
+// options from a theme pack.
+type ThemeValues struct {
+	Day    string         `yaml:"day,omitempty"`
+	Night  string         `yaml:"night,omitempty"`
+	Dusk   string         `yaml:"dusk,omitempty"`
+	Custom map[string]any `yaml:"custom,omitempty"`
+}
+
+// builtinThemes are the packs every config can use. A pack in the
+// config's themes with the same name replaces one.
+var builtinThemes = map[string]ThemePack{
+	"catppuccin": {
+		"cursor":     {Day: "Catppuccin Latte", Night: "Catppuccin Mocha"},
+		"iterm2":     {Day: "Catppuccin Latte", Night: "Catppuccin Mocha"},
+		"neovim":     {Day: "catppuccin-latte", Night: "catppuccin-mocha"},
+		"sublime":    {Day: "Catppuccin Latte.sublime-color-scheme", Night: "Catppuccin Mocha.sublime-color-scheme"},
+		"yazi":       {Day: "catppuccin-latte", Night: "catppuccin-mocha"},
+		"oh-my-posh": {Day: "catppuccin_latte", Night: "catppuccin_mocha"},
+	},
+	"solarized": {
+		"cursor":  {Day: "Solarized Light", Night: "Solarized Dark"},
+		"iterm2":  {Day: "Solarized Light", Night: "Solarized Dark"},
+		"neovim":  {Day: "solarized", Night: "solarized"},
+		"sublime": {Day: "Solarized (light).sublime-color-scheme", Night: "Solarized (dark).sublime-color-scheme"},
+	},
+	"gruvbox": {
+		"cursor": {Day: "Gruvbox Light Medium", Night: "Gruvbox Dark Medium"},
+		"iterm2": {Day: "Gruvbox Light", Night: "Gruvbox Dark"},
+		"neovim": {Day: "gruvbox", Night: "gruvbox"},
+	},
+}
+
+// applyThemes fills in entries' day, night, and dusk values and custom
+// options from their theme pack, or the config's default theme. Values set
+// on an entry win. An entry naming a theme must be covered by it; the
+// default theme skips plugins it doesn't cover.
+func (c Config) applyThemes(entries []ConfigPluginEntry) error {
+	for i := range entries {
+		entry := &entries[i]
+		name := entry.Theme
+		if name == "" {
+			name = c.Theme
+		}
+		if name == "" {
+			continue
+		}
+
+		pack, ok := c.Themes[name]
+		if !ok {
+			pack, ok = builtinThemes[name]
+		}
+		if !ok {
+			return fmt.Errorf("unknown theme %q for plugin %s", name, entry.Name)
+		}
+		values, ok := pack[entry.Name]
+		if !ok {
+			if entry.Theme != "" {
+				return fmt.Errorf("theme %q has no values for plugin %s", name, entry.Name)
+			}
+			continue
+		}
+
+		if entry.Day == "" {
+			entry.Day = values.Day
+		}
+		if entry.Night == "" {
+			entry.Night = values.Night
+		}
+		if entry.Dusk == "" {
+			entry.Dusk = values.Dusk
+		}
+		if len(values.Custom) > 0 {
+			custom := maps.Clone(values.Custom)
+			maps.Copy(custom, entry.Custom)
+			entry.Custom = custom
+		}
+	}
+	return nil
+}
+
+// builtinHighContrast are the high-contrast values accessibility mode uses
+// for entries without their own.
+var builtinHighContrast = map[string]ThemeValues{
+	"cursor":       {Day: "Default High Contrast Light", Night: "Default High Contrast"},
+	"gtk-qt":       {Day: "HighContrast", Night: "HighContrastInverse"},
+	"macos-system": {Custom: map[string]any{"increase_contrast": true}},
+}
+
+// applyAccessibility replaces entries' day and night values with their
+// high-contrast ones and merges in its custom options, when accessibility
+// is on. Dusk is replaced too, even by nothing, so dusk never brings back a
+// low-contrast theme.
+func (c Config) applyAccessibility(entries []ConfigPluginEntry) {
+	if !c.Accessibility {
+		return
+	}
+	for i := range entries {
+		entry := &entries[i]
+		values, ok := builtinHighContrast[entry.Name]
+		if entry.HighContrast != nil {
+			values, ok = *entry.HighContrast, true
+		}
+		if !ok {
+			continue
+		}
+
+		if values.Day != "" {
+			entry.Day = values.Day
+		}
+		if values.Night != "" {
+			entry.Night = values.Night
+		}
+		entry.Dusk = values.Dusk
+		if len(values.Custom) > 0 {
+			custom := maps.Clone(entry.Custom)
+			if custom == nil {
+				custom = map[string]any{}
+			}
+			maps.Copy(custom, values.Custom)
+			entry.Custom = custom
+		}
+	}
+}
+
+// ResetContrast turns Increase Contrast back off in macos-system entries
+// that don't set it themselves, for the runs after accessibility mode is
+// turned off.
+func (c Config) ResetContrast() {
+	if c.Accessibility {
+		return
+	}
+	for i := range c.Plugins {
+		entry := &c.Plugins[i]
+		if _, ok := entry.Custom["increase_contrast"]; entry.Name != "macos-system" || ok {
+			continue
+		}
+		custom := maps.Clone(entry.Custom)
+		if custom == nil {
+			custom = map[string]any{}
+		}
+		custom["increase_contrast"] = false
+		entry.Custom = custom
+	}
+}
@@ -0,0 +1,72 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Announce gives an audible cue at transitions. Day and Night are a sound
+// file to play (afplay, paplay, or aplay) or, if no such file exists, text
+// to speak (say, spd-say, or espeak). Custom "voice" picks the speech
+// voice. An empty value stays silent in that mode.
+func Announce(config PluginConfig) error {
+	cue := config.Night
+	if config.IsLight {
+		cue = config.Day
+	}
+	if cue == "" {
+		return nil
+	}
+
+	path, err := ExpandPath(cue)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		player := firstOnPath("afplay", "paplay", "aplay")
+		if player == "" {
+			return fmt.Errorf("no sound player found: install afplay, paplay, or aplay")
+		}
+		if output, err := run(exec.Command(player, path)); err != nil {
+			return fmt.Errorf("%s failed: %w: %s", player, err, output)
+		}
+		return nil
+	}
+
+	speaker := firstOnPath("say", "spd-say", "espeak")
+	if speaker == "" {
+		return fmt.Errorf("no speech command found: install say, spd-say, or espeak")
+	}
+	var args []string
+	if voice, ok := config.Custom["voice"].(string); ok {
+		flag := "-v"
+		if speaker == "spd-say" {
+			flag = "-y"
+		}
+		args = append(args, flag, voice)
+	}
+	// spd-say returns at once; -w waits so the run log reflects the speech.
+	if speaker == "spd-say" {
+		args = append(args, "-w")
+	}
+	if output, err := run(exec.Command(speaker, append(args, cue)...)); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", speaker, err, output)
+	}
+	return nil
+}
+
+// detectAnnounce checks for a sound player or speech command.
+func detectAnnounce(config PluginConfig) bool {
+	return firstOnPath("afplay", "paplay", "aplay", "say", "spd-say", "espeak") != ""
+}
+
+// firstOnPath returns the first of names found on PATH, or "".
+func firstOnPath(names ...string) string {
+	for _, name := range names {
+		if onPath(name) {
+			return name
+		}
+	}
+	return ""
+}
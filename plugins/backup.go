@@ -0,0 +1,156 @@
+package plugins
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BackupDir is where app files are saved before this tool first changes
+// them, mirroring their absolute paths, so they can be restored on
+// uninstall. Empty disables backups.
+var BackupDir string
+
+// absentSuffix marks a backup of a file that didn't exist, which restoring
+// deletes.
+const absentSuffix = ".dnc-absent"
+
+// journal holds what app files contained before the current run wrote
+// them, while a run is being recorded for Rollback.
+var journal map[string]original
+
+type original struct {
+	data    []byte
+	existed bool
+}
+
+// writeFile is os.WriteFile for app files: it backs up the file's original
+// contents the first time it is changed, and notes its contents before
+// this run if the run is being recorded. A file that already holds data is
+// left alone.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	changed = true
+	if err := backup(path); err != nil {
+		return err
+	}
+	if err := note(path); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+func note(path string) error {
+	if journal == nil {
+		return nil
+	}
+	if _, ok := journal[path]; ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("recording %s: %w", path, err)
+	}
+	journal[path] = original{data: data, existed: err == nil}
+	return nil
+}
+
+// Record starts noting app files' contents before they are written, so a
+// run can be undone with Rollback.
+func Record() {
+	journal = map[string]original{}
+}
+
+// Rollback puts every app file written since Record back as it was,
+// deleting files the run created, and stops recording. Settings changed
+// through commands rather than files are not rolled back.
+func Rollback() error {
+	defer func() { journal = nil }()
+
+	var errs []error
+	for path, orig := range journal {
+		var err error
+		if orig.existed {
+			err = os.WriteFile(path, orig.data, 0644)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func backup(path string) error {
+	if BackupDir == "" {
+		return nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(BackupDir, abs)
+	if exists(dest) || exists(dest+absentSuffix) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+
+	data, err := os.ReadFile(abs)
+	if errors.Is(err, os.ErrNotExist) {
+		return os.WriteFile(dest+absentSuffix, nil, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("backing up %s: %w", path, err)
+	}
+	return os.WriteFile(dest, data, 0644)
+}
+
+// RestoreBackups puts every backed-up file back as it was before this tool
+// first changed it, deleting files it created, then removes the backups.
+// It returns the restored paths.
+func RestoreBackups() ([]string, error) {
+	var restored []string
+	err := filepath.WalkDir(BackupDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		target := strings.TrimPrefix(path, BackupDir)
+		if original, ok := strings.CutSuffix(target, absentSuffix); ok {
+			if err := os.Remove(original); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			restored = append(restored, original)
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return err
+		}
+		restored = append(restored, target)
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return restored, fmt.Errorf("restoring backups: %w", err)
+	}
+	return restored, os.RemoveAll(BackupDir)
+}
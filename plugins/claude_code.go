@@ -1,18 +1,15 @@
 package plugins
 
 import (
-	"os"
 	"path/filepath"
 )
 
 func ClaudeCode(config PluginConfig) error {
-	home, err := os.UserHomeDir()
+	settingsPath, err := claudeCodeSettingsPath(config)
 	if err != nil {
 		return err
 	}
 
-	settingsPath := filepath.Join(home, ".claude/settings.json")
-
 	// Use mode-specific settings from custom field if configured
 	if settings := config.GetModeSettings(); len(settings) > 0 {
 		return UpdateJSONSettings(settingsPath, settings)
@@ -26,3 +23,22 @@ func ClaudeCode(config PluginConfig) error {
 
 	return UpdateJSONTheme(settingsPath, "theme", theme)
 }
+
+func detectClaudeCode(config PluginConfig) bool {
+	settingsPath, err := claudeCodeSettingsPath(config)
+	return err == nil && exists(settingsPath)
+}
+
+func claudeCodeSettingsPath(config PluginConfig) (string, error) {
+	// A custom path targets another settings file, e.g. a project's .claude/settings.json.
+	if path, ok := config.Custom["path"].(string); ok {
+		return ExpandPath(path)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".claude/settings.json"), nil
+}
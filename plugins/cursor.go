@@ -1,21 +1,19 @@
 package plugins
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func Cursor(config PluginConfig) error {
-	home, err := os.UserHomeDir()
+	settingsPath, err := cursorSettingsPath(config)
 	if err != nil {
 		return err
 	}
 
-	settingsPath := filepath.Join(
-		home,
-		"Library/Application Support/Cursor/User/settings.json",
-	)
-
 	// Use mode-specific settings from custom field if configured
 	if settings := config.GetModeSettings(); len(settings) > 0 {
 		return UpdateJSONSettings(settingsPath, settings)
@@ -36,3 +34,126 @@ func Cursor(config PluginConfig) error {
 
 	return UpdateJSONTheme(settingsPath, "workbench.colorTheme", theme)
 }
+
+// captureCursor reads the current theme and VS Code's preferred light and
+// dark themes, which it uses when following the OS appearance.
+func captureCursor(config PluginConfig, isLight bool) (day, night string, err error) {
+	settingsPath, err := cursorSettingsPath(config)
+	if err != nil {
+		return "", "", err
+	}
+	settings, err := readJSONSettings(settingsPath)
+	if err != nil {
+		return "", "", err
+	}
+
+	day, _ = settings["workbench.preferredLightColorTheme"].(string)
+	night, _ = settings["workbench.preferredDarkColorTheme"].(string)
+	if current, ok := settings["workbench.colorTheme"].(string); ok {
+		if isLight {
+			day = current
+		} else {
+			night = current
+		}
+	}
+	return day, night, nil
+}
+
+// uiThemeModes maps the base themes VS Code extensions build on to modes.
+var uiThemeModes = map[string]string{
+	"vs":       "light",
+	"hc-light": "light",
+	"vs-dark":  "dark",
+	"hc-black": "dark",
+}
+
+// vscodeForks maps the folder holding a VS Code fork's settings to where
+// its installed extensions and its app are.
+var vscodeForks = map[string]struct{ extensions, app string }{
+	"Cursor":          {".cursor/extensions", "Cursor.app"},
+	"Code":            {".vscode/extensions", "Visual Studio Code.app"},
+	"Code - Insiders": {".vscode-insiders/extensions", "Visual Studio Code - Insiders.app"},
+	"VSCodium":        {".vscode-oss/extensions", "VSCodium.app"},
+}
+
+// listCursor lists the color themes of the built-in and installed
+// extensions of Cursor, or the fork a custom path points at, by the name
+// workbench.colorTheme takes.
+func listCursor(config PluginConfig) ([]Theme, error) {
+	home, err := homeDir()
+	if err != nil {
+		return nil, err
+	}
+	settingsPath, err := cursorSettingsPath(config)
+	if err != nil {
+		return nil, err
+	}
+	folder := filepath.Base(filepath.Dir(filepath.Dir(settingsPath)))
+	fork, ok := vscodeForks[folder]
+	if !ok {
+		return nil, fmt.Errorf("don't know where %s keeps its extensions", folder)
+	}
+	builtin, _ := filepath.Glob(filepath.Join("/Applications", fork.app, "Contents/Resources/app/extensions/*/package.json"))
+	installed, _ := filepath.Glob(filepath.Join(home, fork.extensions, "*/package.json"))
+
+	var themes []Theme
+	for _, path := range append(builtin, installed...) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var manifest struct {
+			Contributes struct {
+				Themes []struct {
+					ID      string `json:"id"`
+					Label   string `json:"label"`
+					UITheme string `json:"uiTheme"`
+				} `json:"themes"`
+			} `json:"contributes"`
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		var nls map[string]any
+		for _, theme := range manifest.Contributes.Themes {
+			name := theme.ID
+			if name == "" {
+				name = theme.Label
+			}
+			// Built-in labels are keys into the extension's translations.
+			if key, ok := strings.CutPrefix(name, "%"); ok && strings.HasSuffix(key, "%") {
+				if nls == nil {
+					nls, _ = readJSONSettings(filepath.Join(filepath.Dir(path), "package.nls.json"))
+				}
+				if label, ok := nls[strings.TrimSuffix(key, "%")].(string); ok {
+					name = label
+				}
+			}
+			themes = append(themes, Theme{name, uiThemeModes[theme.UITheme]})
+		}
+	}
+	return themes, nil
+}
+
+func detectCursor(config PluginConfig) bool {
+	settingsPath, err := cursorSettingsPath(config)
+	return err == nil && exists(settingsPath)
+}
+
+func cursorSettingsPath(config PluginConfig) (string, error) {
+	// A custom path points this entry at another VS Code fork.
+	if path, ok := config.Custom["path"].(string); ok {
+		return ExpandPath(path)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(
+		home,
+		"Library/Application Support/Cursor/User/settings.json",
+	), nil
+}
@@ -0,0 +1,51 @@
+package plugins
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Defaults writes arbitrary macOS defaults, which many apps keep their
+// theme in. Custom "light_values" and "dark_values" list the writes for
+// each mode, each with a domain, key, and value. The value's YAML type
+// picks the defaults type (bool, int, float, or string); "type" overrides
+// it. Apps read most defaults at launch, so pair it with reload.relaunch.
+func Defaults(config PluginConfig) error {
+	values, err := config.modeValues()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		domain, _ := v["domain"].(string)
+		key, _ := v["key"].(string)
+		if _, ok := v["value"]; !ok || domain == "" || key == "" {
+			return fmt.Errorf("defaults value %v: domain, key, and value are required", v)
+		}
+
+		typ, _ := v["type"].(string)
+		if typ == "" {
+			switch v["value"].(type) {
+			case bool:
+				typ = "bool"
+			case int:
+				typ = "int"
+			case float64:
+				typ = "float"
+			default:
+				typ = "string"
+			}
+		}
+		switch typ {
+		case "bool", "int", "float", "string":
+		default:
+			return fmt.Errorf("defaults %s %s: invalid type %q: want bool, int, float, or string", domain, key, typ)
+		}
+
+		value := fmt.Sprint(v["value"])
+		if output, err := run(exec.Command("defaults", "write", domain, key, "-"+typ, value)); err != nil {
+			return fmt.Errorf("defaults write %s %s failed: %w: %s", domain, key, err, output)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// EnvFile writes DNC_MODE and the variables in custom "light_env" or
+// "dark_env" to a KEY=VALUE file that shells, scripts, and systemd units
+// can source, by default ~/.cache/day-night-cycle/mode.env. Values are
+// single-quoted for sh.
+func EnvFile(config PluginConfig) error {
+	path, err := envFilePath(config)
+	if err != nil {
+		return err
+	}
+
+	mode, key := "dark", "dark_env"
+	if config.IsLight {
+		mode, key = "light", "light_env"
+	}
+	env := map[string]string{"DNC_MODE": mode}
+	vars, _ := config.Custom[key].(map[string]any)
+	for name, value := range vars {
+		env[name] = fmt.Sprint(value)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Auto-generated by day-night-cycle\n")
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		fmt.Fprintf(&b, "%s='%s'\n", name, strings.ReplaceAll(env[name], "'", `'\''`))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFile(path, []byte(b.String()), 0644)
+}
+
+func envFilePath(config PluginConfig) (string, error) {
+	if path, ok := config.Custom["path"].(string); ok {
+		return ExpandPath(path)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache/day-night-cycle/mode.env"), nil
+}
@@ -0,0 +1,127 @@
+package plugins
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// External plugins are executables installed into the plugins directory by
+// "plugin install". Each run executes one as "<plugin> light" or
+// "<plugin> dark" with an ExternalRequest as JSON on stdin; a non-zero exit
+// is a failure, and whatever it prints is logged.
+
+// ExternalRequest is what an external plugin reads on stdin.
+type ExternalRequest struct {
+	Mode      string         `json:"mode"`           // "light" or "dark"
+	Dusk      bool           `json:"dusk,omitempty"` // Night mode is the dusk variant
+	Level     float64        `json:"level"`          // Progress from night (0) to day (1)
+	Elevation float64        `json:"elevation"`      // Sun elevation in degrees
+	Day       string         `json:"day,omitempty"`
+	Night     string         `json:"night,omitempty"`
+	DuskValue string         `json:"duskValue,omitempty"`
+	Custom    map[string]any `json:"custom,omitempty"`
+}
+
+// Installed records where an installed external plugin came from, and the
+// checksum it must still have to be run.
+type Installed struct {
+	Source  string `json:"source"`  // e.g. github.com/user/dnc-plugin-foo
+	Version string `json:"version"` // Release tag
+	SHA256  string `json:"sha256"`  // Hex SHA-256 of the binary
+}
+
+// lockFile lists the installed external plugins by name, in the plugins
+// directory.
+const lockFile = "plugins.lock"
+
+// ReadLock returns the external plugins installed in dir.
+func ReadLock(dir string) (map[string]Installed, error) {
+	data, err := os.ReadFile(filepath.Join(dir, lockFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]Installed{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lock map[string]Installed
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", lockFile, err)
+	}
+	return lock, nil
+}
+
+// WriteLock records the external plugins installed in dir.
+func WriteLock(dir string, lock map[string]Installed) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, lockFile), append(data, '\n'), 0644)
+}
+
+// LoadExternal registers the external plugins installed in dir under
+// their names, so configs can use them like built-in ones. Built-in names
+// win.
+func LoadExternal(dir string) error {
+	lock, err := ReadLock(dir)
+	if err != nil {
+		return err
+	}
+	for name, installed := range lock {
+		if _, ok := Registry[name]; ok {
+			continue
+		}
+		Registry[name] = external(filepath.Join(dir, name), installed.SHA256)
+		infos[name] = Info{Description: fmt.Sprintf("External plugin from %s %s", installed.Source, installed.Version)}
+	}
+	return nil
+}
+
+// external runs the binary at path, refusing when it no longer has the
+// locked checksum: it was tampered with or replaced outside "plugin
+// install".
+func external(path, sha string) Plugin {
+	return func(config PluginConfig) error {
+		binary, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(binary)
+		if got := hex.EncodeToString(sum[:]); got != sha {
+			return fmt.Errorf("refusing to run %s: its SHA-256 is %s, but plugins.lock has %q; reinstall it with \"plugin install\"", filepath.Base(path), got, sha)
+		}
+
+		req := ExternalRequest{
+			Mode:      "dark",
+			Dusk:      config.IsDusk,
+			Level:     config.Level,
+			Elevation: config.Elevation,
+			Day:       config.Day,
+			Night:     config.Night,
+			DuskValue: config.Dusk,
+			Custom:    config.Custom,
+		}
+		if config.IsLight {
+			req.Mode = "light"
+		}
+		input, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+
+		cmd := exec.Command(path, req.Mode)
+		cmd.Stdin = bytes.NewReader(input)
+		if _, err := run(cmd); err != nil {
+			return fmt.Errorf("%s failed: %w", filepath.Base(path), err)
+		}
+		return nil
+	}
+}
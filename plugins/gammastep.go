@@ -0,0 +1,99 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// Gammastep sets the screen color temperature on Linux with gammastep or
+// redshift in one-shot mode, so the change happens exactly at the
+// transitions this tool schedules. Day and Night are temperatures in
+// Kelvin; custom "tool" selects redshift or wlsunset instead of gammastep,
+// and "stop_running" stops a running gammastep or redshift first. During a
+// gradual transition the temperature is interpolated between the two.
+func Gammastep(config PluginConfig) error {
+	day, err := kelvin(config.Day, 6500)
+	if err != nil {
+		return err
+	}
+	night, err := kelvin(config.Night, 4000)
+	if err != nil {
+		return err
+	}
+
+	temperature := night + int(float64(day-night)*config.Level)
+
+	tool := "gammastep"
+	if t, ok := config.Custom["tool"].(string); ok {
+		tool = t
+	}
+	if tool != "gammastep" && tool != "redshift" && tool != "wlsunset" {
+		return fmt.Errorf("unsupported tool %q: want gammastep, redshift, or wlsunset", tool)
+	}
+
+	if _, err := exec.LookPath(tool); err != nil {
+		return fmt.Errorf("%s not found in PATH", tool)
+	}
+
+	if tool == "wlsunset" {
+		return wlsunset(temperature)
+	}
+
+	// A continuously running instance follows its own schedule and would
+	// immediately undo a one-shot adjustment. Stopping it is opt-in, since
+	// it may be what the user runs the rest of the time. pkill exits 1
+	// when there was none.
+	if stop, _ := config.Custom["stop_running"].(bool); stop {
+		var exitErr *exec.ExitError
+		if output, err := run(exec.Command("pkill", "-x", tool)); err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			return fmt.Errorf("stopping the running %s failed: %w: %s", tool, err, output)
+		}
+	}
+
+	// -P resets the existing gamma ramps so temperatures don't stack.
+	if output, err := run(exec.Command(tool, "-P", "-O", strconv.Itoa(temperature))); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", tool, err, output)
+	}
+
+	return nil
+}
+
+// wlsunset has no one-shot mode, so the running instance is replaced by one
+// whose day and night temperatures are 1K apart around temperature, which
+// it holds until the next transition replaces it. With the two that close,
+// the location it is given doesn't matter.
+func wlsunset(temperature int) error {
+	var exitErr *exec.ExitError
+	if output, err := run(exec.Command("pkill", "-x", "wlsunset")); err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return fmt.Errorf("stopping the running wlsunset failed: %w: %s", err, output)
+	}
+
+	cmd := exec.Command("wlsunset",
+		"-t", strconv.Itoa(temperature), "-T", strconv.Itoa(temperature+1),
+		"-l", "0", "-L", "0")
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting wlsunset: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+func kelvin(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	k, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid temperature %q: want Kelvin, e.g. 4000", value)
+	}
+	return k, nil
+}
+
+func detectGammastep(config PluginConfig) bool {
+	tool, ok := config.Custom["tool"].(string)
+	if !ok {
+		tool = "gammastep"
+	}
+	return onPath(tool)
+}
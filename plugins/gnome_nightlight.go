@@ -0,0 +1,70 @@
+package plugins
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+const nightLightSchema = "org.gnome.settings-daemon.plugins.color"
+
+// GNOMENightLight turns GNOME's Night Light on or off through gsettings.
+// Day and Night are read by nightLight. While on, its own schedule is set
+// to cover the whole day, so this tool's transitions decide when it
+// applies.
+func GNOMENightLight(config PluginConfig) error {
+	on, temperature, err := nightLight(config)
+	if err != nil {
+		return err
+	}
+
+	settings := [][2]string{{"night-light-enabled", "false"}}
+	if on {
+		settings = [][2]string{
+			// Equal start and end times make the manual schedule span all day.
+			{"night-light-schedule-automatic", "false"},
+			{"night-light-schedule-from", "0.0"},
+			{"night-light-schedule-to", "0.0"},
+			{"night-light-enabled", "true"},
+		}
+		if temperature != "" {
+			settings = append([][2]string{{"night-light-temperature", temperature}}, settings...)
+		}
+	}
+
+	for _, s := range settings {
+		if output, err := run(exec.Command("gsettings", "set", nightLightSchema, s[0], s[1])); err != nil {
+			return fmt.Errorf("gsettings set %s failed: %w: %s", s[0], err, output)
+		}
+	}
+	return nil
+}
+
+// nightLight reads Day or Night for a desktop night light: "on", "off", or
+// a temperature in Kelvin, which also turns it on. By default it is off by
+// day and on at its current temperature by night. During a gradual
+// transition a night temperature fades toward 6500K with the daylight.
+func nightLight(config PluginConfig) (on bool, temperature string, err error) {
+	value := config.Night
+	if config.IsLight {
+		value = config.Day
+	}
+	if config.Level > 0 && config.Level < 1 {
+		if night, err := strconv.Atoi(config.Night); err == nil {
+			value = strconv.Itoa(night + int(float64(6500-night)*config.Level))
+		}
+	}
+
+	switch value {
+	case "":
+		return !config.IsLight, "", nil
+	case "on":
+		return true, "", nil
+	case "off":
+		return false, "", nil
+	}
+	if _, err := strconv.Atoi(value); err != nil {
+		return false, "", fmt.Errorf("invalid night light value %q: want on, off, or a temperature in Kelvin", value)
+	}
+	return true, value, nil
+}
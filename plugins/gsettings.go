@@ -0,0 +1,39 @@
+package plugins
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// GSettings sets arbitrary GSettings keys, covering GNOME apps without a
+// plugin of their own. Custom "light_values" and "dark_values" list the
+// keys for each mode, each with a schema, key, and value. "path" names the
+// path of a relocatable schema, like a GNOME Terminal profile. Values are
+// passed as GVariant text, so a bare word is a string.
+func GSettings(config PluginConfig) error {
+	values, err := config.modeValues()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		schema, _ := v["schema"].(string)
+		key, _ := v["key"].(string)
+		if _, ok := v["value"]; !ok || schema == "" || key == "" {
+			return fmt.Errorf("gsettings value %v: schema, key, and value are required", v)
+		}
+		if path, _ := v["path"].(string); path != "" {
+			schema += ":" + path
+		}
+
+		value := fmt.Sprint(v["value"])
+		if output, err := run(exec.Command("gsettings", "set", schema, key, value)); err != nil {
+			return fmt.Errorf("gsettings set %s %s failed: %w: %s", schema, key, err, output)
+		}
+	}
+	return nil
+}
+
+func detectGSettings(config PluginConfig) bool {
+	return onPath("gsettings")
+}
@@ -0,0 +1,70 @@
+package plugins
+
+import (
+	"os/exec"
+	"path/filepath"
+)
+
+// GTKQt sets the GTK theme and dark preference for GTK 3 and 4, plus the Qt
+// style via qt5ct/qt6ct and Kvantum, so apps outside GNOME follow the mode.
+// Day and Night are GTK theme names. light_qt_style/dark_qt_style set the
+// qt5ct/qt6ct style (e.g. "kvantum", "Fusion") and light_kvantum/dark_kvantum
+// the Kvantum theme.
+func GTKQt(config PluginConfig) error {
+	theme := config.Night
+	defaultTheme := "Adwaita-dark"
+	preferDark := "1"
+	colorScheme := "prefer-dark"
+	prefix := "dark_"
+
+	if config.IsLight {
+		theme = config.Day
+		defaultTheme = "Adwaita"
+		preferDark = "0"
+		colorScheme = "default"
+		prefix = "light_"
+	}
+
+	if theme == "" {
+		theme = defaultTheme
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return err
+	}
+
+	gtkSettings := map[string]string{
+		"gtk-theme-name":                    theme,
+		"gtk-application-prefer-dark-theme": preferDark,
+	}
+	for _, dir := range []string{"gtk-3.0", "gtk-4.0"} {
+		path := filepath.Join(home, ".config", dir, "settings.ini")
+		if err := UpdateINISettings(path, "Settings", gtkSettings); err != nil {
+			return err
+		}
+	}
+
+	// Running GTK apps only pick up changes through the settings daemon.
+	// Best effort: gsettings is absent outside GNOME-based desktops.
+	run(exec.Command("gsettings", "set", "org.gnome.desktop.interface", "gtk-theme", theme))
+	run(exec.Command("gsettings", "set", "org.gnome.desktop.interface", "color-scheme", colorScheme))
+
+	if style, ok := config.Custom[prefix+"qt_style"].(string); ok {
+		for _, dir := range []string{"qt5ct", "qt6ct"} {
+			path := filepath.Join(home, ".config", dir, dir+".conf")
+			if err := UpdateINISettings(path, "Appearance", map[string]string{"style": style}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if kvantum, ok := config.Custom[prefix+"kvantum"].(string); ok {
+		path := filepath.Join(home, ".config/Kvantum/kvantum.kvconfig")
+		if err := UpdateINISettings(path, "General", map[string]string{"theme": kvantum}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
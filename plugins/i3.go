@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// I3 swaps the color include file for i3 or sway and, optionally, polybar,
+// then reloads them. Day and Night are color files copied over the include
+// target (~/.config/i3/colors or ~/.config/sway/colors), which the main
+// config pulls in with an include directive. light_polybar and dark_polybar
+// are copied over ~/.config/polybar/colors.ini.
+func I3(config PluginConfig) error {
+	source := config.Night
+	polybarKey := "dark_polybar"
+	if config.IsLight {
+		source = config.Day
+		polybarKey = "light_polybar"
+	}
+
+	wm := "i3"
+	if w, ok := config.Custom["wm"].(string); ok {
+		wm = w
+	}
+	if wm != "i3" && wm != "sway" {
+		return fmt.Errorf("unsupported wm %q: want i3 or sway", wm)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return err
+	}
+
+	if source != "" {
+		if err := copyColors(source, filepath.Join(home, ".config", wm, "colors")); err != nil {
+			return err
+		}
+
+		msg := "i3-msg"
+		if wm == "sway" {
+			msg = "swaymsg"
+		}
+		// Best effort: the window manager may not be running.
+		run(exec.Command(msg, "reload"))
+	}
+
+	if polybar, ok := config.Custom[polybarKey].(string); ok {
+		if err := copyColors(polybar, filepath.Join(home, ".config/polybar/colors.ini")); err != nil {
+			return err
+		}
+		run(exec.Command("polybar-msg", "cmd", "restart"))
+	}
+
+	return nil
+}
+
+func copyColors(source, target string) error {
+	sourcePath, err := ExpandPath(source)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", sourcePath, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	return writeFile(target, data, 0644)
+}
+
+func detectI3(config PluginConfig) bool {
+	wm, ok := config.Custom["wm"].(string)
+	if !ok {
+		wm = "i3"
+	}
+	return onPath(wm)
+}
@@ -0,0 +1,60 @@
+package plugins
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// Icons switches the desktop icon theme and, with light_cursor/dark_cursor,
+// the cursor theme. Day and Night are icon theme names. Settings go to
+// gsettings for GNOME-based desktops, GTK settings.ini for everything else,
+// and ~/.icons/default/index.theme, which X11 uses for the default cursor.
+func Icons(config PluginConfig) error {
+	iconTheme := config.Night
+	cursorKey := "dark_cursor"
+	if config.IsLight {
+		iconTheme = config.Day
+		cursorKey = "light_cursor"
+	}
+
+	cursorTheme, _ := config.Custom[cursorKey].(string)
+
+	if iconTheme == "" && cursorTheme == "" {
+		mode := "night"
+		if config.IsLight {
+			mode = "day"
+		}
+		return fmt.Errorf("missing %s icon theme configuration", mode)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return err
+	}
+
+	gtkSettings := map[string]string{}
+	if iconTheme != "" {
+		gtkSettings["gtk-icon-theme-name"] = iconTheme
+		// Best effort: gsettings is absent outside GNOME-based desktops.
+		run(exec.Command("gsettings", "set", "org.gnome.desktop.interface", "icon-theme", iconTheme))
+	}
+	if cursorTheme != "" {
+		gtkSettings["gtk-cursor-theme-name"] = cursorTheme
+		run(exec.Command("gsettings", "set", "org.gnome.desktop.interface", "cursor-theme", cursorTheme))
+
+		indexPath := filepath.Join(home, ".icons/default/index.theme")
+		if err := UpdateINISettings(indexPath, "Icon Theme", map[string]string{"Inherits": cursorTheme}); err != nil {
+			return err
+		}
+	}
+
+	for _, dir := range []string{"gtk-3.0", "gtk-4.0"} {
+		path := filepath.Join(home, ".config", dir, "settings.ini")
+		if err := UpdateINISettings(path, "Settings", gtkSettings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
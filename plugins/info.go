@@ -0,0 +1,376 @@
+package plugins
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Info describes a plugin for "plugin list", config validation, and
+// skipping plugins on systems they don't support.
+type Info struct {
+	Description string
+	OS          []string // GOOS values the plugin works on; empty for any
+	Running     string   // Process the plugin only reaches while it runs; the default requiresRunning
+	Closed      string   // Process that overwrites the plugin's changes while it runs; the default requiresClosed
+	Keys        []Key    // Options read from custom
+	System      bool     // Changes settings a --home-dir trial can't redirect: the OS, running apps, or other machines
+}
+
+// Key is one custom option a plugin reads. Type is "string", "bool",
+// "list" (of strings), "maps" (a list of maps), "settings" (a map
+// written into the app's settings), or "env" (a map of shell variables). Values, when set, are the only strings
+// allowed.
+type Key struct {
+	Name        string
+	Type        string
+	Description string
+	Values      []string
+}
+
+var (
+	macOS        = []string{"darwin"}
+	linux        = []string{"linux"}
+	windowsOrWSL = []string{"windows", "linux"}
+)
+
+// pathKey and settingsKeys are the options of plugins for JSON settings
+// files.
+var (
+	pathKey      = Key{"path", "string", "Settings file path, overriding the default location", nil}
+	settingsKeys = []Key{
+		{"day", "settings", "Settings to apply in day mode", nil},
+		{"night", "settings", "Settings to apply in night mode", nil},
+		{"dusk", "settings", "Settings that replace night's during dusk and dawn", nil},
+	}
+)
+
+// infos holds the description of every plugin in Registry.
+var infos = map[string]Info{
+	"iterm2": {
+		Description: "iTerm2 color preset of every open session",
+		OS:          macOS,
+		Running:     "iTerm2",
+	},
+	"cursor": {
+		Description: "Cursor color theme and settings; custom path targets another VS Code fork",
+		Keys:        append([]Key{pathKey}, settingsKeys...),
+	},
+	"claude-code": {
+		Description: "Claude Code theme and settings in ~/.claude/settings.json",
+		Keys:        append([]Key{pathKey}, settingsKeys...),
+	},
+	"neovim": {
+		Description: "Neovim colorscheme through ~/.config/nvim/theme.lua, reloaded in running instances",
+		System:      true,
+	},
+	"qutebrowser": {
+		Description: "qutebrowser preferred web page color scheme and settings, over IPC or in autoconfig.yml",
+		System:      true,
+		Keys:        settingsKeys,
+	},
+	"vivaldi": {
+		Description: "Vivaldi theme by ID, applied at its next launch",
+		OS:          []string{"darwin", "linux"},
+		Closed:      vivaldiProcess,
+	},
+	"oh-my-posh": {
+		Description: "oh-my-posh prompt theme, copied to one file the shell profile points at",
+	},
+	"nushell": {
+		Description: "Nushell color_config through a generated theme.nu",
+		Keys: []Key{
+			{"path", "string", "theme.nu path, overriding the one in Nushell's config directory", nil},
+			{"day", "settings", "color_config entries for day mode", nil},
+			{"night", "settings", "color_config entries for night mode", nil},
+			{"dusk", "settings", "color_config entries that replace night's during dusk and dawn", nil},
+		},
+	},
+	"neomutt": {
+		Description: "neomutt colorscheme and aerc styleset, read at their next start",
+		Keys: []Key{
+			{"path", "string", "Generated colors file for neomuttrc to source (default ~/.config/neomutt/colors.muttrc)", nil},
+			{"light_styleset", "string", "aerc styleset for day mode", nil},
+			{"dark_styleset", "string", "aerc styleset for night mode", nil},
+		},
+	},
+	"weechat": {
+		Description: "WeeChat options and a command, live through the fifo plugin or saved by weechat-headless",
+		OS:          []string{"darwin", "linux"},
+		System:      true,
+		Keys: []Key{
+			{"day", "settings", "WeeChat options to /set in day mode", nil},
+			{"night", "settings", "WeeChat options to /set in night mode", nil},
+			{"dusk", "settings", "WeeChat options that replace night's during dusk and dawn", nil},
+		},
+	},
+	"yazi": {
+		Description: "yazi flavor and ranger colorscheme, read at their next start",
+		Keys: []Key{
+			{"light_ranger", "string", "ranger colorscheme for day mode", nil},
+			{"dark_ranger", "string", "ranger colorscheme for night mode", nil},
+		},
+	},
+	"taskwarrior": {
+		Description: "taskwarrior and timewarrior theme includes",
+		Keys: []Key{
+			{"light_timewarrior", "string", "timewarrior theme file for day mode", nil},
+			{"dark_timewarrior", "string", "timewarrior theme file for night mode", nil},
+		},
+	},
+	"macos-system": {
+		Description: "macOS light or dark appearance",
+		OS:          macOS,
+		System:      true,
+		Keys: []Key{
+			{"light_wallpaper", "string", "Legacy: wallpaper for day mode (prefer the wallpaper plugin)", nil},
+			{"dark_wallpaper", "string", "Legacy: wallpaper for night mode (prefer the wallpaper plugin)", nil},
+			{"increase_contrast", "bool", "Turn the Increase Contrast accessibility setting on or off (on in accessibility mode); takes effect at the next login", nil},
+		},
+	},
+	"sublime": {
+		Description: "Sublime Text color scheme",
+		OS:          macOS,
+	},
+	"pycharm": {
+		Description: "PyCharm look and feel, applied at its next launch",
+		OS:          macOS,
+	},
+	"obs": {
+		Description: "OBS Studio theme and scene collection, applied at its next launch",
+		OS:          macOS,
+		Keys: []Key{
+			{"light_scene_collection", "string", "Scene collection to load in day mode", nil},
+			{"dark_scene_collection", "string", "Scene collection to load in night mode", nil},
+		},
+	},
+	"wallpaper": {
+		Description: "Desktop picture, file or rotating folder, per display",
+		OS:          macOS,
+		System:      true,
+		Keys: []Key{
+			{"light_displays", "list", "Per-display day wallpapers in System Events order; empty entries leave a display alone", nil},
+			{"dark_displays", "list", "Per-display night wallpapers in System Events order; empty entries leave a display alone", nil},
+			{"pick", "string", "Set one image from a folder at each transition instead of letting macOS rotate: random or sequential (one step per day)", []string{"random", "sequential"}},
+		},
+	},
+	"nightshift": {
+		Description: "Night Shift on, off, or strength, through the nightlight CLI",
+		OS:          macOS,
+		System:      true,
+	},
+	"gammastep": {
+		Description: "Screen color temperature with gammastep, redshift, or wlsunset",
+		OS:          linux,
+		System:      true,
+		Keys: []Key{
+			{"tool", "string", "gammastep (default), redshift, or wlsunset (restarted at each transition)", []string{"gammastep", "redshift", "wlsunset"}},
+			{"stop_running", "bool", "Stop a running gammastep or redshift first, which would otherwise undo the change", nil},
+		},
+	},
+	"gnome-nightlight": {
+		Description: "GNOME Night Light on, off, or temperature, through gsettings",
+		OS:          linux,
+		System:      true,
+	},
+	"kde-nightcolor": {
+		Description: "Plasma Night Color on, off, or temperature, through kwinrc",
+		OS:          linux,
+	},
+	"rofi": {
+		Description: "rofi or wofi launcher theme",
+		OS:          linux,
+		Keys: []Key{
+			{"launcher", "string", "rofi (default) or wofi", []string{"rofi", "wofi"}},
+		},
+	},
+	"i3": {
+		Description: "i3 or sway colors file, and polybar colors, then reloads them",
+		OS:          linux,
+		Keys: []Key{
+			{"wm", "string", "i3 (default) or sway", []string{"i3", "sway"}},
+			{"light_polybar", "string", "Polybar colors file for day mode", nil},
+			{"dark_polybar", "string", "Polybar colors file for night mode", nil},
+		},
+	},
+	"gtk-qt": {
+		Description: "GTK theme and color scheme, and the Qt style through qt5ct/qt6ct and Kvantum",
+		OS:          linux,
+		System:      true,
+		Keys: []Key{
+			{"light_qt_style", "string", "qt5ct/qt6ct style for day mode", nil},
+			{"dark_qt_style", "string", "qt5ct/qt6ct style for night mode", nil},
+			{"light_kvantum", "string", "Kvantum theme for day mode", nil},
+			{"dark_kvantum", "string", "Kvantum theme for night mode", nil},
+		},
+	},
+	"icons": {
+		Description: "Desktop icon theme and cursor theme",
+		OS:          linux,
+		System:      true,
+		Keys: []Key{
+			{"light_cursor", "string", "Cursor theme for day mode", nil},
+			{"dark_cursor", "string", "Cursor theme for night mode", nil},
+		},
+	},
+	"wsl": {
+		Description: "Windows app and system theme, from inside WSL",
+		OS:          linux,
+		System:      true,
+		Keys: []Key{
+			{"system", "bool", "Also switch the Windows system theme (default true)", nil},
+		},
+	},
+	"remote": {
+		Description: "Day or night shell command, run on another machine over SSH",
+		System:      true,
+		Keys: []Key{
+			{"host", "string", "SSH host to run the day/night command on", nil},
+		},
+	},
+	"shortcuts": {
+		Description: "Apple Shortcut named by day or night, run with the shortcuts CLI",
+		OS:          macOS,
+		System:      true,
+	},
+	"defaults": {
+		Description: "Arbitrary macOS defaults (domain, key, value) per mode",
+		OS:          macOS,
+		System:      true,
+		Keys: []Key{
+			{"light_values", "maps", "defaults to write in day mode: domain, key, value, and optional type (bool, int, float, string)", nil},
+			{"dark_values", "maps", "defaults to write in night mode: domain, key, value, and optional type (bool, int, float, string)", nil},
+		},
+	},
+	"gsettings": {
+		Description: "Arbitrary GSettings keys (schema, key, value) per mode",
+		OS:          linux,
+		System:      true,
+		Keys: []Key{
+			{"light_values", "maps", "Keys to set in day mode: schema, key, value, and optional path for relocatable schemas", nil},
+			{"dark_values", "maps", "Keys to set in night mode: schema, key, value, and optional path for relocatable schemas", nil},
+		},
+	},
+	"registry": {
+		Description: "Arbitrary Windows registry values (hive, path, name, type, value) per mode, on Windows or from WSL",
+		OS:          windowsOrWSL,
+		System:      true,
+		Keys: []Key{
+			{"light_values", "maps", "Values to write in day mode: path, name, value, and optional hive (default HKCU) and type (REG_DWORD, REG_SZ, ...)", nil},
+			{"dark_values", "maps", "Values to write in night mode: path, name, value, and optional hive (default HKCU) and type (REG_DWORD, REG_SZ, ...)", nil},
+		},
+	},
+	"envfile": {
+		Description: "DNC_MODE and other variables in a KEY=VALUE file for shells and scripts to source",
+		Keys: []Key{
+			{"path", "string", "File to write (default ~/.cache/day-night-cycle/mode.env)", nil},
+			{"light_env", "env", "Variables to write in day mode", nil},
+			{"dark_env", "env", "Variables to write in night mode", nil},
+		},
+	},
+	"announce": {
+		Description: "Sound file played, or text spoken, at each transition",
+		System:      true,
+		Keys: []Key{
+			{"voice", "string", "Speech voice, e.g. Samantha for say or en+f3 for espeak", nil},
+		},
+	},
+}
+
+// Describe returns the named plugin's description. ok is false for names
+// not in Registry.
+func Describe(name string) (info Info, ok bool) {
+	info, ok = infos[name]
+	return info, ok
+}
+
+// Supported reports whether the plugin works on this operating
+// system.
+func (info Info) Supported() bool {
+	return len(info.OS) == 0 || slices.Contains(info.OS, runtime.GOOS)
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidEnvName reports whether name can be used as a shell variable name
+// without quoting.
+func ValidEnvName(name string) bool {
+	return envNamePattern.MatchString(name)
+}
+
+// Validate checks that name is a plugin and that the custom options it
+// reads have the right types. Options it doesn't read are left alone.
+func Validate(name string, custom map[string]any) error {
+	info, ok := infos[name]
+	if !ok {
+		return fmt.Errorf("unknown plugin %q", name)
+	}
+	for _, key := range info.Keys {
+		value, ok := custom[key.Name]
+		if !ok {
+			continue
+		}
+		// Secrets are resolved just before the plugin runs.
+		if ref, ok := value.(map[string]any); ok && ref["secretRef"] != nil {
+			continue
+		}
+		if err := key.check(value); err != nil {
+			return fmt.Errorf("custom %s: %w", key.Name, err)
+		}
+	}
+	return nil
+}
+
+func (key Key) check(value any) error {
+	switch key.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("want a string, got %v", value)
+		}
+		if len(key.Values) > 0 && !slices.Contains(key.Values, s) {
+			return fmt.Errorf("invalid value %q: want %s", s, strings.Join(key.Values, " or "))
+		}
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("want true or false, got %v", value)
+		}
+	case "list":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("want a list, got %v", value)
+		}
+		for _, item := range items {
+			if _, ok := item.(string); !ok {
+				return fmt.Errorf("want a list of strings, got %v", item)
+			}
+		}
+	case "maps":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("want a list, got %v", value)
+		}
+		for _, item := range items {
+			if _, ok := item.(map[string]any); !ok {
+				return fmt.Errorf("want a list of maps, got %v", item)
+			}
+		}
+	case "settings":
+		if _, ok := value.(map[string]any); !ok {
+			return fmt.Errorf("want a map of settings, got %v", value)
+		}
+	case "env":
+		vars, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("want a map of variables, got %v", value)
+		}
+		for name := range vars {
+			if !ValidEnvName(name) {
+				return fmt.Errorf("invalid variable %q: want letters, digits, and underscores, not starting with a digit", name)
+			}
+		}
+	}
+	return nil
+}
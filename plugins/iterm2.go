@@ -1,8 +1,10 @@
 package plugins
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 )
 
 func ITerm2(config PluginConfig) error {
@@ -34,10 +36,66 @@ end tell
 `, preset)
 
 	cmd := exec.Command("osascript", "-e", script)
-	output, err := cmd.CombinedOutput()
+	output, err := run(cmd)
 	if err != nil {
 		return fmt.Errorf("osascript failed: %w: %s", err, output)
 	}
 
 	return nil
 }
+
+// captureITerm2 can't capture anything: sessions only expose their colors,
+// not the name of the preset they came from.
+func captureITerm2(config PluginConfig, isLight bool) (day, night string, err error) {
+	return "", "", fmt.Errorf("iTerm2 doesn't record which color preset is in use; set day and night presets")
+}
+
+// iTerm2Presets are the color presets iTerm2 ships with.
+var iTerm2Presets = []Theme{
+	{"Dark Background", "dark"},
+	{"Light Background", "light"},
+	{"Pastel (Dark Background)", "dark"},
+	{"Smoooooth", "dark"},
+	{"Solarized Dark", "dark"},
+	{"Solarized Light", "light"},
+	{"Tango Dark", "dark"},
+	{"Tango Light", "light"},
+}
+
+// listITerm2 lists the built-in color presets and those imported into
+// iTerm2, judging the imported ones light or dark by their background.
+func listITerm2(config PluginConfig) ([]Theme, error) {
+	home, err := homeDir()
+	if err != nil {
+		return nil, err
+	}
+	themes := append([]Theme(nil), iTerm2Presets...)
+
+	plist := filepath.Join(home, "Library/Preferences/com.googlecode.iterm2.plist")
+	output, err := exec.Command("plutil", "-extract", "Custom Color Presets", "json", "-o", "-", plist).Output()
+	if err != nil {
+		// Nothing has been imported.
+		return themes, nil
+	}
+	var presets map[string]struct {
+		Background map[string]any `json:"Background Color"`
+	}
+	if err := json.Unmarshal(output, &presets); err != nil {
+		return nil, fmt.Errorf("failed to parse iTerm2 color presets: %w", err)
+	}
+	for name, preset := range presets {
+		r, _ := preset.Background["Red Component"].(float64)
+		g, _ := preset.Background["Green Component"].(float64)
+		b, _ := preset.Background["Blue Component"].(float64)
+		mode := "dark"
+		if 0.2126*r+0.7152*g+0.0722*b > 0.5 {
+			mode = "light"
+		}
+		themes = append(themes, Theme{name, mode})
+	}
+	return themes, nil
+}
+
+func detectITerm2(config PluginConfig) bool {
+	return exists("/Applications/iTerm.app")
+}
@@ -0,0 +1,40 @@
+package plugins
+
+import (
+	"os/exec"
+	"path/filepath"
+)
+
+// KDENightColor turns Plasma's Night Color on or off in kwinrc and has
+// KWin reload it. Day and Night are read by nightLight. While on, it is
+// set to constant mode, so this tool's transitions decide when it applies.
+func KDENightColor(config PluginConfig) error {
+	on, temperature, err := nightLight(config)
+	if err != nil {
+		return err
+	}
+
+	settings := map[string]string{"Active": "false"}
+	if on {
+		settings = map[string]string{"Active": "true", "Mode": "Constant"}
+		if temperature != "" {
+			settings["NightTemperature"] = temperature
+		}
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return err
+	}
+	if err := UpdateINISettings(filepath.Join(home, ".config", "kwinrc"), "NightColor", settings); err != nil {
+		return err
+	}
+
+	// Best effort: without a running KWin the change applies at login.
+	run(exec.Command("dbus-send", "--session", "--type=method_call", "--dest=org.kde.KWin", "/KWin", "org.kde.KWin.reconfigure"))
+	return nil
+}
+
+func detectKDENightColor(config PluginConfig) bool {
+	return onPath("kwin_wayland") || onPath("kwin_x11")
+}
@@ -2,17 +2,21 @@ package plugins
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
+	"strconv"
 )
 
+// MacOSSystem sets the macOS appearance. The native helper needs no
+// Automation permission; builds without it, or macOS versions without the
+// call it uses, go through System Events.
 func MacOSSystem(config PluginConfig) error {
-	darkMode := "true"
-	if config.IsLight {
-		darkMode = "false"
-	}
+	if !nativeSetDarkMode(!config.IsLight) {
+		darkMode := "true"
+		if config.IsLight {
+			darkMode = "false"
+		}
 
-	script := fmt.Sprintf(`
+		script := fmt.Sprintf(`
 tell application "System Events"
 	tell appearance preferences
 		set dark mode to %s
@@ -20,37 +24,36 @@ tell application "System Events"
 end tell
 `, darkMode)
 
-	cmd := exec.Command("osascript", "-e", script)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("osascript failed: %w: %s", err, output)
+		cmd := exec.Command("osascript", "-e", script)
+		if output, err := run(cmd); err != nil {
+			return fmt.Errorf("osascript failed: %w: %s", err, output)
+		}
+	}
+
+	// Accessibility mode turns on Increase Contrast, and off again after.
+	// Writing it needs the terminal, or launchd's binary, to have Full Disk
+	// Access, and macOS only reads it at the next login.
+	if on, ok := config.Custom["increase_contrast"].(bool); ok {
+		cmd := exec.Command("defaults", "write", "com.apple.universalaccess", "increaseContrast", "-bool", strconv.FormatBool(on))
+		if output, err := run(cmd); err != nil {
+			return fmt.Errorf("setting Increase Contrast failed (grant Full Disk Access): %w: %s", err, output)
+		}
 	}
 
-	// Optional wallpaper support
+	// Legacy wallpaper keys; new configs should use the wallpaper plugin.
 	wallpaperKey := "dark_wallpaper"
 	if config.IsLight {
 		wallpaperKey = "light_wallpaper"
 	}
 
 	if wallpaper, ok := config.Custom[wallpaperKey].(string); ok {
-		fullPath, err := ExpandPath(wallpaper)
-		if err != nil {
-			return err
-		}
-
-		if _, err := os.Stat(fullPath); err != nil {
-			fmt.Printf("    Warning: wallpaper file not found: %s\n", fullPath)
-			return nil
-		}
-
-		wallpaperScript := fmt.Sprintf(`
-tell application "Finder"
-	set desktop picture to POSIX file "%s"
-end tell
-`, fullPath)
-
-		cmd := exec.Command("osascript", "-e", wallpaperScript)
-		_ = cmd.Run()
+		return setWallpaper("every desktop", wallpaper)
 	}
 
 	return nil
 }
+
+// captureMacOS has nothing to capture: the appearance itself is the mode.
+func captureMacOS(config PluginConfig, isLight bool) (day, night string, err error) {
+	return "", "", nil
+}
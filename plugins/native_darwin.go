@@ -0,0 +1,106 @@
+//go:build darwin && cgo
+
+package plugins
+
+/*
+#cgo CFLAGS: -x objective-c -fobjc-arc
+#cgo LDFLAGS: -framework AppKit
+#import <AppKit/AppKit.h>
+#include <dlfcn.h>
+#include <stdbool.h>
+#include <stdlib.h>
+#include <unistd.h>
+
+// isDarkMode reads AppleInterfaceStyle from the global preferences.
+static bool isDarkMode(void) {
+	CFPreferencesAppSynchronize(kCFPreferencesAnyApplication);
+	CFStringRef style = CFPreferencesCopyAppValue(CFSTR("AppleInterfaceStyle"), kCFPreferencesAnyApplication);
+	if (style == NULL) {
+		return false;
+	}
+	bool dark = CFStringCompare(style, CFSTR("Dark"), 0) == kCFCompareEqualTo;
+	CFRelease(style);
+	return dark;
+}
+
+// setDarkMode calls SkyLight's SLSSetAppearanceThemeLegacy, which the
+// Appearance settings use. It is looked up at runtime because it is
+// private, and returns false if it is missing or the appearance hasn't
+// changed a second later, e.g. because a macOS update changed what it does.
+static bool setDarkMode(bool dark) {
+	void *skylight = dlopen("/System/Library/PrivateFrameworks/SkyLight.framework/SkyLight", RTLD_LAZY);
+	if (skylight == NULL) {
+		return false;
+	}
+	void (*set)(bool) = dlsym(skylight, "SLSSetAppearanceThemeLegacy");
+	if (set == NULL) {
+		return false;
+	}
+	set(dark);
+	for (int i = 0; i < 10; i++) {
+		if (isDarkMode() == dark) {
+			return true;
+		}
+		usleep(100000);
+	}
+	return false;
+}
+
+static bool onMainThread(void) {
+	return [NSThread isMainThread];
+}
+
+// setDesktopImage sets the picture on every display. It returns an error
+// message for the caller to free, or NULL.
+static char *setDesktopImage(const char *path) {
+	@autoreleasepool {
+		NSURL *url = [NSURL fileURLWithPath:[NSString stringWithUTF8String:path]];
+		for (NSScreen *screen in [NSScreen screens]) {
+			NSError *error = nil;
+			if (![[NSWorkspace sharedWorkspace] setDesktopImageURL:url forScreen:screen options:@{} error:&error]) {
+				return strdup(error.localizedDescription.UTF8String);
+			}
+		}
+		return NULL;
+	}
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+const hasNativeHelper = true
+
+// AppKit's screen list may only be used from the main thread, so keep the
+// main goroutine, which applies modes outside the daemon, on it.
+func init() {
+	runtime.LockOSThread()
+}
+
+// nativeSetDarkMode switches the system appearance without System Events,
+// so no Automation permission is needed. It reports false if it couldn't.
+func nativeSetDarkMode(dark bool) bool {
+	return bool(C.setDarkMode(C.bool(dark)))
+}
+
+// nativeSetDesktopImage sets an image file as the picture on every display
+// through NSWorkspace. handled is false off the main thread, where the
+// caller falls back to AppleScript.
+func nativeSetDesktopImage(path string) (handled bool, err error) {
+	if !C.onMainThread() {
+		return false, nil
+	}
+
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	if message := C.setDesktopImage(cPath); message != nil {
+		defer C.free(unsafe.Pointer(message))
+		return true, fmt.Errorf("setting desktop picture: %s", C.GoString(message))
+	}
+	return true, nil
+}
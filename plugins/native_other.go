@@ -0,0 +1,14 @@
+//go:build !darwin || !cgo
+
+package plugins
+
+// Builds without cgo have no native helper, so callers use AppleScript.
+const hasNativeHelper = false
+
+func nativeSetDarkMode(dark bool) bool {
+	return false
+}
+
+func nativeSetDesktopImage(path string) (handled bool, err error) {
+	return false, nil
+}
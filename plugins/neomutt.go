@@ -0,0 +1,75 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Neomutt points terminal mail at the mode's colors. For neomutt, Day and
+// Night are colorscheme files, sourced from a generated colors.muttrc that
+// neomuttrc sources; custom "light_styleset" and "dark_styleset" set
+// aerc's styleset-name. Either client can be used alone. Both read their
+// colors at startup.
+func Neomutt(config PluginConfig) error {
+	colorscheme, key := config.Night, "dark_styleset"
+	if config.IsLight {
+		colorscheme, key = config.Day, "light_styleset"
+	}
+	styleset, _ := config.Custom[key].(string)
+	if colorscheme == "" && styleset == "" {
+		return fmt.Errorf("set day and night to neomutt colorscheme files, or custom %s for aerc", key)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return err
+	}
+
+	if colorscheme != "" {
+		path, err := ExpandPath(colorscheme)
+		if err != nil {
+			return err
+		}
+		colorsPath, err := neomuttColorsPath(config)
+		if err != nil {
+			return err
+		}
+		content := fmt.Sprintf("# Auto-generated by day-night-cycle\nsource %q\n", path)
+		if err := os.MkdirAll(filepath.Dir(colorsPath), 0755); err != nil {
+			return err
+		}
+		if err := writeFile(colorsPath, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	if styleset != "" {
+		aercConf := filepath.Join(home, ".config/aerc/aerc.conf")
+		if runtime.GOOS == "darwin" {
+			aercConf = filepath.Join(home, "Library/Preferences/aerc/aerc.conf")
+		}
+		if err := UpdateINISettings(aercConf, "ui", map[string]string{"styleset-name": styleset}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func neomuttColorsPath(config PluginConfig) (string, error) {
+	if path, ok := config.Custom["path"].(string); ok {
+		return ExpandPath(path)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config/neomutt/colors.muttrc"), nil
+}
+
+func detectNeomutt(config PluginConfig) bool {
+	return onPath("neomutt") || onPath("aerc")
+}
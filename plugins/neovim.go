@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 func Neovim(config PluginConfig) error {
@@ -16,7 +18,7 @@ func Neovim(config PluginConfig) error {
 		colorscheme = config.Day
 	}
 
-	home, err := os.UserHomeDir()
+	home, err := homeDir()
 	if err != nil {
 		return err
 	}
@@ -41,7 +43,7 @@ vim.o.background = "%s"
 		return err
 	}
 
-	if err := os.WriteFile(themePath, []byte(content), 0644); err != nil {
+	if err := writeFile(themePath, []byte(content), 0644); err != nil {
 		return err
 	}
 
@@ -54,11 +56,51 @@ vim.o.background = "%s"
 func notifyNeovim(themePath string) {
 	// Try nvr (neovim-remote) first
 	cmd := exec.Command("nvr", "--remote-expr", fmt.Sprintf("luafile %s", themePath))
-	_ = cmd.Run()
+	run(cmd)
 
 	// Try nvim --remote-expr as fallback
 	if cmd.ProcessState != nil && !cmd.ProcessState.Success() {
 		cmd = exec.Command("nvim", "--remote-expr", fmt.Sprintf("luafile %s", themePath))
-		_ = cmd.Run()
+		run(cmd)
 	}
 }
+
+var colorschemePattern = regexp.MustCompile(`vim\.cmd\.colorscheme\("([^"]+)"\)`)
+
+// captureNeovim reads the colorscheme from the theme file this plugin
+// writes.
+func captureNeovim(config PluginConfig, isLight bool) (day, night string, err error) {
+	home, err := homeDir()
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, ".config/nvim/theme.lua"))
+	if err != nil {
+		return "", "", fmt.Errorf("no theme file to capture: %w", err)
+	}
+	match := colorschemePattern.FindSubmatch(data)
+	if match == nil {
+		return "", "", nil
+	}
+	return forMode(string(match[1]), isLight)
+}
+
+// listNeovim asks nvim, with the user's config and plugins loaded, for the
+// colorschemes on its runtimepath.
+func listNeovim(config PluginConfig) ([]Theme, error) {
+	output, err := exec.Command("nvim", "--headless",
+		`+lua io.stdout:write(table.concat(vim.fn.getcompletion("", "color"), "\n"))`, "+qa").Output()
+	if err != nil {
+		return nil, fmt.Errorf("nvim failed: %w", err)
+	}
+	var themes []Theme
+	for name := range strings.FieldsSeq(string(output)) {
+		themes = append(themes, Theme{Name: name})
+	}
+	return themes, nil
+}
+
+func detectNeovim(config PluginConfig) bool {
+	return onPath("nvim")
+}
@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// NightShift controls macOS Night Shift through the nightlight CLI
+// (brew install smudge/nightlight/nightlight), a shim over the private
+// CoreBrightness framework. Day and Night accept "on", "off", or a
+// strength from 0 to 100, which also turns Night Shift on. During a
+// gradual transition a numeric night strength fades with the daylight.
+func NightShift(config PluginConfig) error {
+	value := config.Night
+	if config.IsLight {
+		value = config.Day
+	}
+
+	if config.Level > 0 && config.Level < 1 {
+		if strength, err := strconv.Atoi(config.Night); err == nil {
+			value = strconv.Itoa(int(float64(strength) * (1 - config.Level)))
+			if value == "0" {
+				value = "off"
+			}
+		}
+	}
+
+	if value == "" {
+		value = "on"
+		if config.IsLight {
+			value = "off"
+		}
+	}
+
+	if _, err := exec.LookPath("nightlight"); err != nil {
+		return fmt.Errorf("nightlight CLI not found (brew install smudge/nightlight/nightlight)")
+	}
+
+	if value != "on" && value != "off" {
+		strength, err := strconv.Atoi(value)
+		if err != nil || strength < 0 || strength > 100 {
+			return fmt.Errorf("invalid Night Shift value %q: want on, off, or 0-100", value)
+		}
+		if output, err := run(exec.Command("nightlight", "temp", value)); err != nil {
+			return fmt.Errorf("nightlight temp failed: %w: %s", err, output)
+		}
+		value = "on"
+	}
+
+	if output, err := run(exec.Command("nightlight", value)); err != nil {
+		return fmt.Errorf("nightlight %s failed: %w: %s", value, err, output)
+	}
+
+	return nil
+}
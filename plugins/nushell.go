@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Nushell writes theme.nu, for config.nu to source, setting color_config
+// for the mode: from the theme file in Day or Night (such as one from
+// nu_scripts), or std's light-theme or dark-theme. Entries in custom
+// "day", "night", or "dusk" are merged over it. New shells pick it up.
+func Nushell(config PluginConfig) error {
+	mode, theme := "dark", config.Night
+	if config.IsLight {
+		mode, theme = "light", config.Day
+	}
+
+	var b strings.Builder
+	b.WriteString("# Auto-generated by day-night-cycle\n")
+	fmt.Fprintf(&b, "# Set colors for %s mode\n", mode)
+	if theme != "" {
+		path, err := ExpandPath(theme)
+		if err != nil {
+			return err
+		}
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "source %s\n", nuString(path))
+	} else {
+		fmt.Fprintf(&b, "use std/config %s-theme\n", mode)
+		fmt.Fprintf(&b, "$env.config.color_config = (%s-theme)\n", mode)
+	}
+	if settings := config.GetModeSettings(); len(settings) > 0 {
+		// JSON objects are Nushell record literals.
+		record, err := json.Marshal(settings)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "$env.config.color_config = ($env.config.color_config | merge %s)\n", record)
+	}
+
+	path, err := nushellThemePath(config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFile(path, []byte(b.String()), 0644)
+}
+
+// nuString quotes s as a Nushell string.
+func nuString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func nushellThemePath(config PluginConfig) (string, error) {
+	if path, ok := config.Custom["path"].(string); ok {
+		return ExpandPath(path)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library/Application Support/nushell/theme.nu"), nil
+	}
+	return filepath.Join(home, ".config/nushell/theme.nu"), nil
+}
+
+func detectNushell(config PluginConfig) bool {
+	return onPath("nu")
+}
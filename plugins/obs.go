@@ -0,0 +1,75 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// OBS sets the OBS Studio UI theme and, optionally, the active scene
+// collection. OBS reads these at startup and rewrites them on exit, so the
+// change applies the next time OBS is launched.
+func OBS(config PluginConfig) error {
+	home, err := homeDir()
+	if err != nil {
+		return err
+	}
+
+	obsDir := filepath.Join(home, "Library/Application Support/obs-studio")
+
+	// OBS 30 moved UI settings from global.ini to user.ini and switched to
+	// reverse-DNS theme IDs.
+	iniPath := filepath.Join(obsDir, "user.ini")
+	themeSection, themeKey := "Appearance", "Theme"
+	theme := config.Night
+	defaultTheme := "com.obsproject.Yami.Original"
+	if config.IsLight {
+		theme = config.Day
+		defaultTheme = "com.obsproject.Yami.Light"
+	}
+
+	if _, err := os.Stat(iniPath); err != nil {
+		iniPath = filepath.Join(obsDir, "global.ini")
+		themeSection, themeKey = "General", "CurrentTheme3"
+		defaultTheme = "Yami"
+		if config.IsLight {
+			defaultTheme = "Light"
+		}
+	}
+
+	if _, err := os.Stat(iniPath); err != nil {
+		return fmt.Errorf("OBS settings not found in %s", obsDir)
+	}
+
+	if theme == "" {
+		theme = defaultTheme
+	}
+
+	if err := UpdateINISettings(iniPath, themeSection, map[string]string{themeKey: theme}); err != nil {
+		return err
+	}
+
+	collectionKey := "dark_scene_collection"
+	if config.IsLight {
+		collectionKey = "light_scene_collection"
+	}
+
+	if collection, ok := config.Custom[collectionKey].(string); ok {
+		// SceneCollectionFile is the JSON file name in basic/scenes without
+		// its extension; OBS loads by file, so both must match.
+		return UpdateINISettings(iniPath, "Basic", map[string]string{
+			"SceneCollection":     collection,
+			"SceneCollectionFile": collection,
+		})
+	}
+
+	return nil
+}
+
+func detectOBS(config PluginConfig) bool {
+	home, err := homeDir()
+	if err != nil {
+		return false
+	}
+	return exists(filepath.Join(home, "Library/Application Support/obs-studio"))
+}
@@ -0,0 +1,70 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OhMyPosh copies the day or night oh-my-posh theme over one fixed file,
+// ~/.cache/day-night-cycle/oh-my-posh.omp.json (.yaml or .toml after the
+// theme's format), which the shell profile points oh-my-posh at once with
+// --config or POSH_THEME, so new prompts follow the mode in PowerShell,
+// bash, zsh, and fish alike. Day and Night are theme files, or names of
+// the themes bundled in $POSH_THEMES_PATH.
+func OhMyPosh(config PluginConfig) error {
+	theme := config.Night
+	if config.IsLight {
+		theme = config.Day
+	}
+	if theme == "" {
+		return fmt.Errorf("set day and night to oh-my-posh theme files or names")
+	}
+
+	src, err := poshThemePath(theme)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("reading theme: %w", err)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(home, ".cache/day-night-cycle/oh-my-posh.omp"+filepath.Ext(src))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return writeFile(dst, data, 0644)
+}
+
+// poshThemePath resolves a theme file, or the name of a bundled theme.
+func poshThemePath(theme string) (string, error) {
+	if dir := os.Getenv("POSH_THEMES_PATH"); dir != "" && !strings.ContainsAny(theme, `/\.`) {
+		return filepath.Join(dir, theme+".omp.json"), nil
+	}
+	return ExpandPath(theme)
+}
+
+// listOhMyPosh lists the themes bundled in $POSH_THEMES_PATH.
+func listOhMyPosh(config PluginConfig) ([]Theme, error) {
+	dir := os.Getenv("POSH_THEMES_PATH")
+	if dir == "" {
+		return nil, fmt.Errorf("POSH_THEMES_PATH is not set")
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.omp.*"))
+	var themes []Theme
+	for _, path := range files {
+		name, _, _ := strings.Cut(filepath.Base(path), ".omp.")
+		themes = append(themes, Theme{Name: name})
+	}
+	return themes, nil
+}
+
+func detectOhMyPosh(config PluginConfig) bool {
+	return onPath("oh-my-posh")
+}
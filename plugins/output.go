@@ -0,0 +1,44 @@
+package plugins
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+// captured holds what the plugins' commands printed since TakeOutput was
+// last called.
+var captured bytes.Buffer
+
+// changed records whether the plugins wrote a file with new contents or
+// ran a command since ResetChanged was last called.
+var changed bool
+
+// run runs cmd and returns its combined stdout and stderr, like
+// CombinedOutput, keeping a copy for the run log. Commands are how plugins
+// change settings that aren't in files, so running one counts as a change.
+func run(cmd *exec.Cmd) ([]byte, error) {
+	changed = true
+	out, err := cmd.CombinedOutput()
+	captured.Write(out)
+	return out, err
+}
+
+// ResetChanged starts tracking a plugin's changes for Changed.
+func ResetChanged() {
+	changed = false
+}
+
+// Changed reports whether the plugins changed anything since
+// ResetChanged: a file written with new contents, or a command run.
+func Changed() bool {
+	return changed
+}
+
+// TakeOutput returns what the plugins' commands printed since it was last
+// called, trimmed, and forgets it. Call it after each plugin so the output
+// is logged with the plugin that caused it.
+func TakeOutput() string {
+	defer captured.Reset()
+	return strings.TrimSpace(captured.String())
+}
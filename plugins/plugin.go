@@ -1,33 +1,224 @@
 package plugins
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 )
 
 // PluginConfig provides theme configuration to plugins.
 // This is the source of truth for plugin configuration structure.
 type PluginConfig struct {
-	IsLight bool           `yaml:"-"`             // Whether to apply day mode (set at runtime)
-	Day     string         `yaml:"day,omitempty"`  // Primary day mode value (theme/preset/colorscheme)
-	Night   string         `yaml:"night,omitempty"` // Primary night mode value (theme/preset/colorscheme)
-	Custom  map[string]any `yaml:"custom,omitempty"` // Additional plugin-specific configuration (supports "day" and "night" keys for mode-specific settings)
+	IsLight   bool           `yaml:"-"`                // Whether to apply day mode (set at runtime)
+	IsDusk    bool           `yaml:"-"`                // Whether night mode is the dusk variant (set at runtime)
+	Level     float64        `yaml:"-"`                // Progress from night (0) to day (1) during a gradual transition (set at runtime)
+	Elevation float64        `yaml:"-"`                // Sun elevation in degrees, negative below the horizon (set at runtime)
+	MoonPhase float64        `yaml:"-"`                // Lunar cycle from new (0) through full (0.5) back to new (set at runtime)
+	HTTP      *http.Client   `yaml:"-"`                // Shared client honoring the http proxy/TLS config, for network plugins (set at runtime)
+	Day       string         `yaml:"day,omitempty"`    // Primary day mode value (theme/preset/colorscheme)
+	Night     string         `yaml:"night,omitempty"`  // Primary night mode value (theme/preset/colorscheme)
+	Dusk      string         `yaml:"dusk,omitempty"`   // Replaces Night during dusk and dawn, when dusk mode is enabled
+	Custom    map[string]any `yaml:"custom,omitempty"` // Additional plugin-specific configuration (supports "day" and "night" keys for mode-specific settings)
 }
 
 // Plugin is the signature for all plugin functions.
 type Plugin func(config PluginConfig) error
 
-// Registry holds all registered plugins.
+// Registry holds all registered plugins. Each has an Info in infos.
 var Registry = map[string]Plugin{
-	"iterm2":       ITerm2,
-	"cursor":       Cursor,
-	"claude-code":  ClaudeCode,
-	"neovim":       Neovim,
-	"macos-system": MacOSSystem,
-	"sublime":      Sublime,
-	"pycharm":      PyCharm,
+	"iterm2":           ITerm2,
+	"cursor":           Cursor,
+	"claude-code":      ClaudeCode,
+	"neovim":           Neovim,
+	"macos-system":     MacOSSystem,
+	"sublime":          Sublime,
+	"pycharm":          PyCharm,
+	"obs":              OBS,
+	"wallpaper":        Wallpaper,
+	"nightshift":       NightShift,
+	"gammastep":        Gammastep,
+	"rofi":             Rofi,
+	"i3":               I3,
+	"gtk-qt":           GTKQt,
+	"icons":            Icons,
+	"wsl":              WSL,
+	"remote":           Remote,
+	"shortcuts":        Shortcuts,
+	"announce":         Announce,
+	"defaults":         Defaults,
+	"gsettings":        GSettings,
+	"registry":         WindowsRegistry,
+	"envfile":          EnvFile,
+	"gnome-nightlight": GNOMENightLight,
+	"kde-nightcolor":   KDENightColor,
+	"qutebrowser":      Qutebrowser,
+	"vivaldi":          Vivaldi,
+	"oh-my-posh":       OhMyPosh,
+	"nushell":          Nushell,
+	"neomutt":          Neomutt,
+	"weechat":          Weechat,
+	"yazi":             Yazi,
+	"taskwarrior":      Taskwarrior,
+}
+
+// Detector reports whether a plugin's application is present on this
+// machine. Plugins without a detector are present on every operating
+// system their Info supports.
+type Detector func(config PluginConfig) bool
+
+// detectors holds the detection check for each plugin that has one.
+var detectors = map[string]Detector{
+	"iterm2":           detectITerm2,
+	"cursor":           detectCursor,
+	"claude-code":      detectClaudeCode,
+	"neovim":           detectNeovim,
+	"sublime":          detectSublime,
+	"pycharm":          detectPyCharm,
+	"obs":              detectOBS,
+	"gammastep":        detectGammastep,
+	"rofi":             detectRofi,
+	"i3":               detectI3,
+	"wsl":              detectWSL,
+	"shortcuts":        detectShortcuts,
+	"announce":         detectAnnounce,
+	"gsettings":        detectGSettings,
+	"registry":         detectRegistry,
+	"gnome-nightlight": detectGSettings,
+	"kde-nightcolor":   detectKDENightColor,
+	"qutebrowser":      detectQutebrowser,
+	"vivaldi":          detectVivaldi,
+	"oh-my-posh":       detectOhMyPosh,
+	"nushell":          detectNushell,
+	"neomutt":          detectNeomutt,
+	"weechat":          detectWeechat,
+	"yazi":             detectYazi,
+	"taskwarrior":      detectTaskwarrior,
+}
+
+// automates lists the apps each plugin sends Apple events to, which macOS
+// asks the user to allow under Automation.
+var automates = map[string][]string{
+	"iterm2":       {"iTerm"},
+	"macos-system": {"System Events"},
+	"wallpaper":    {"System Events"},
+}
+
+// AutomationTargets returns the apps the named plugin scripts with
+// osascript. The native helper sets the appearance without System Events.
+func AutomationTargets(name string) []string {
+	if name == "macos-system" && hasNativeHelper {
+		return nil
+	}
+	return automates[name]
+}
+
+// Capturer reads an app's current theme, so a starter config can keep
+// what the user already has. isLight is the mode the system is in: the
+// current theme is that mode's value. Apps that record a theme for each
+// mode, like VS Code, fill in both.
+type Capturer func(config PluginConfig, isLight bool) (day, night string, err error)
+
+// capturers holds the capture function for each plugin that has one.
+var capturers = map[string]Capturer{
+	"iterm2":       captureITerm2,
+	"cursor":       captureCursor,
+	"neovim":       captureNeovim,
+	"macos-system": captureMacOS,
+	"sublime":      captureSublime,
+	"pycharm":      capturePyCharm,
+}
+
+// Capture reads the named plugin's current theme. ok is false for plugins
+// that can't be captured.
+func Capture(name string, config PluginConfig, isLight bool) (day, night string, ok bool, err error) {
+	capture, ok := capturers[name]
+	if !ok {
+		return "", "", false, nil
+	}
+	day, night, err = capture(config, isLight)
+	return day, night, true, err
+}
+
+// Theme is one theme a plugin's day or night value can name. Mode is
+// "light" or "dark" when the app says which, otherwise empty.
+type Theme struct {
+	Name string
+	Mode string
+}
+
+// Lister returns the themes installed for an app, so a config can name
+// them exactly.
+type Lister func(config PluginConfig) ([]Theme, error)
+
+// listers holds the theme lister for each plugin that has one.
+var listers = map[string]Lister{
+	"iterm2":     listITerm2,
+	"cursor":     listCursor,
+	"neovim":     listNeovim,
+	"sublime":    listSublime,
+	"pycharm":    listPyCharm,
+	"yazi":       listYazi,
+	"oh-my-posh": listOhMyPosh,
+}
+
+// Themes lists the named plugin's installed themes, sorted by name. ok is
+// false for plugins that can't list them.
+func Themes(name string, config PluginConfig) (themes []Theme, ok bool, err error) {
+	list, ok := listers[name]
+	if !ok {
+		return nil, false, nil
+	}
+	themes, err = list(config)
+	slices.SortFunc(themes, func(a, b Theme) int { return strings.Compare(a.Name, b.Name) })
+	return slices.CompactFunc(themes, func(a, b Theme) bool { return a.Name == b.Name }), true, err
+}
+
+// forMode returns theme as the day or night value for the mode an app is in.
+func forMode(theme string, isLight bool) (day, night string, err error) {
+	if isLight {
+		return theme, "", nil
+	}
+	return "", theme, nil
+}
+
+// readJSONSettings reads a JSON settings file.
+func readJSONSettings(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// Detect reports whether the named plugin's application is present.
+func Detect(name string, config PluginConfig) bool {
+	if !infos[name].Supported() {
+		return false
+	}
+	detect, ok := detectors[name]
+	return !ok || detect(config)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func onPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
 }
 
 func UpdateJSONTheme(path, key, value string) error {
@@ -52,7 +243,7 @@ func UpdateJSONTheme(path, key, value string) error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(path, output, 0644); err != nil {
+	if err := writeFile(path, output, 0644); err != nil {
 		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
 
@@ -87,16 +278,189 @@ func UpdateJSONSettings(path string, updates map[string]any) error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(path, output, 0644); err != nil {
+	if err := writeFile(path, output, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// UpdateNestedJSON sets values in an existing JSON file of nested objects,
+// like Chromium's Preferences, by dotted path: "a.b.c" is c within b
+// within a. Missing objects along a path are created. Numbers are kept
+// exactly and the file stays compact.
+func UpdateNestedJSON(path string, updates map[string]any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var settings map[string]any
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&settings); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for key, value := range updates {
+		parts := strings.Split(key, ".")
+		m := settings
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := m[part].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				m[part] = next
+			}
+			m = next
+		}
+		m[parts[len(parts)-1]] = value
+	}
+
+	output, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := writeFile(path, output, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// UpdateINISettings sets keys within one section of an INI file, leaving
+// every other line untouched. Missing keys are appended to the section, a
+// missing section is appended to the file, and a missing file is created.
+func UpdateINISettings(path, section string, updates map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+	header := "[" + section + "]"
+
+	start, end := -1, len(lines)
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		if start == -1 {
+			if trimmed == header {
+				start = i
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			end = i
+			break
+		}
+	}
+
+	if start == -1 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, header)
+		start, end = len(lines)-1, len(lines)
+	}
+
+	// Drop trailing blank lines so new keys stay attached to the section.
+	for end-1 > start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	remaining := make(map[string]string, len(updates))
+	for k, v := range updates {
+		remaining[k] = v
+	}
+
+	for i := start + 1; i < end; i++ {
+		key, _, ok := strings.Cut(lines[i], "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if v, ok := remaining[key]; ok {
+			lines[i] = key + "=" + v
+			delete(remaining, key)
+		}
+	}
+
+	keys := make([]string, 0, len(remaining))
+	for k := range remaining {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	added := make([]string, 0, len(keys))
+	for _, k := range keys {
+		added = append(added, k+"="+remaining[k])
+	}
+
+	lines = append(lines[:end], append(added, lines[end:]...)...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	output := strings.Join(lines, "\n") + "\n"
+	if err := writeFile(path, []byte(output), 0644); err != nil {
 		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
 
 	return nil
 }
 
+// SetConfigLine replaces every line of a line-based config file whose
+// whitespace-separated fields match, or appends line when none does. A
+// missing file is created.
+func SetConfigLine(path string, match func(fields []string) bool, line string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+	found := false
+	for i, l := range lines {
+		if match(strings.Fields(l)) {
+			lines[i] = line
+			found = true
+		}
+	}
+	if !found {
+		lines = append(lines, line)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := writeFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// HomeDir, when set, replaces the user's home directory for every path the
+// plugins resolve, so a run can be tried against a throwaway tree.
+var HomeDir string
+
+// homeDir returns HomeDir, or the user's home directory.
+func homeDir() (string, error) {
+	if HomeDir != "" {
+		return HomeDir, nil
+	}
+	return os.UserHomeDir()
+}
+
 func ExpandPath(path string) (string, error) {
 	if len(path) > 0 && path[0] == '~' {
-		home, err := os.UserHomeDir()
+		home, err := homeDir()
 		if err != nil {
 			return "", err
 		}
@@ -115,6 +479,12 @@ func (c PluginConfig) GetModeSettings() map[string]any {
 		key = "day"
 	}
 
+	if c.IsDusk {
+		if settings, ok := c.Custom["dusk"].(map[string]any); ok {
+			return settings
+		}
+	}
+
 	settings, ok := c.Custom[key].(map[string]any)
 	if !ok {
 		return nil
@@ -122,3 +492,24 @@ func (c PluginConfig) GetModeSettings() map[string]any {
 
 	return settings
 }
+
+// modeValues returns the list of maps under custom "light_values" or
+// "dark_values" for the mode, for plugins that write arbitrary values like
+// defaults and gsettings.
+func (c PluginConfig) modeValues() ([]map[string]any, error) {
+	key := "dark_values"
+	if c.IsLight {
+		key = "light_values"
+	}
+	list, _ := c.Custom[key].([]any)
+
+	values := make([]map[string]any, 0, len(list))
+	for i, item := range list {
+		value, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d]: expected a map, got %v", key, i, item)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
@@ -1,9 +1,14 @@
 package plugins
 
 import (
+	"archive/zip"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 func PyCharm(config PluginConfig) error {
@@ -22,7 +27,7 @@ func PyCharm(config PluginConfig) error {
 		themeID = config.Night
 	}
 
-	home, err := os.UserHomeDir()
+	home, err := homeDir()
 	if err != nil {
 		return err
 	}
@@ -51,21 +56,124 @@ func PyCharm(config PluginConfig) error {
 		return fmt.Errorf("PyCharm configuration directory not found in %s", jetbrainsDir)
 	}
 
-	// Create options directory if it doesn't exist
-	optionsDir := filepath.Join(pycharmDir, "options")
-	if err := os.MkdirAll(optionsDir, 0755); err != nil {
+	// Update only the theme, keeping LafManager's other settings.
+	lafPath := filepath.Join(pycharmDir, "options", "laf.xml")
+	const lafManager = `application/component[@name="LafManager"]`
+	if err := UpdateXMLAttributes(lafPath, lafManager, map[string]string{"autodetect": "false"}); err != nil {
 		return err
 	}
+	return UpdateXMLAttributes(lafPath, lafManager+"/laf", map[string]string{"class-name": lafClass, "themeId": themeID})
+}
+
+var themeIDPattern = regexp.MustCompile(`themeId="([^"]+)"`)
+
+func capturePyCharm(config PluginConfig, isLight bool) (day, night string, err error) {
+	home, err := homeDir()
+	if err != nil {
+		return "", "", err
+	}
+
+	lafPaths, _ := filepath.Glob(filepath.Join(home, "Library/Application Support/JetBrains/PyCharm*/options/laf.xml"))
+	if len(lafPaths) == 0 {
+		return "", "", fmt.Errorf("PyCharm laf.xml not found")
+	}
+	data, err := os.ReadFile(lafPaths[0])
+	if err != nil {
+		return "", "", err
+	}
+	match := themeIDPattern.FindSubmatch(data)
+	if match == nil {
+		return "", "", nil
+	}
+	return forMode(string(match[1]), isLight)
+}
+
+// pyCharmThemes are the themes PyCharm ships with, by themeId.
+var pyCharmThemes = []Theme{
+	{"Darcula", "dark"},
+	{"IntelliJ", "light"},
+	{"ExperimentalDark", "dark"},
+	{"ExperimentalLight", "light"},
+	{"ExperimentalLightWithLightHeader", "light"},
+	{"JetBrainsHighContrastTheme", "dark"},
+}
+
+var (
+	themeProviderPattern = regexp.MustCompile(`<themeProvider\s[^>]*>`)
+	themeAttrPattern     = regexp.MustCompile(`\b(id|path)="([^"]+)"`)
+)
+
+// listPyCharm lists the built-in themes and those of installed theme
+// plugins, whose jars declare them in plugin.xml.
+func listPyCharm(config PluginConfig) ([]Theme, error) {
+	home, err := homeDir()
+	if err != nil {
+		return nil, err
+	}
+	themes := append([]Theme(nil), pyCharmThemes...)
+
+	jars, _ := filepath.Glob(filepath.Join(home, "Library/Application Support/JetBrains/PyCharm*/plugins/*/lib/*.jar"))
+	for _, path := range jars {
+		jarThemes, err := jarThemes(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		themes = append(themes, jarThemes...)
+	}
+	return themes, nil
+}
 
-	lafPath := filepath.Join(optionsDir, "laf.xml")
+// jarThemes returns the themes a plugin jar provides, judged light or dark
+// by their theme files.
+func jarThemes(path string) ([]Theme, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
 
-	// Create the laf.xml content
-	content := fmt.Sprintf(`<application>
-  <component name="LafManager" autodetect="false">
-    <laf class-name="%s" themeId="%s" />
-  </component>
-</application>
-`, lafClass, themeID)
+	pluginXML, err := readZipFile(&r.Reader, "META-INF/plugin.xml")
+	if err != nil {
+		// Libraries bundled with a plugin have none.
+		return nil, nil
+	}
+	var themes []Theme
+	for _, tag := range themeProviderPattern.FindAll(pluginXML, -1) {
+		attrs := map[string]string{}
+		for _, m := range themeAttrPattern.FindAllSubmatch(tag, -1) {
+			attrs[string(m[1])] = string(m[2])
+		}
+		theme := Theme{Name: attrs["id"]}
+		if data, err := readZipFile(&r.Reader, strings.TrimPrefix(attrs["path"], "/")); err == nil {
+			var file struct {
+				Dark bool `json:"dark"`
+			}
+			if json.Unmarshal(data, &file) == nil {
+				theme.Mode = "light"
+				if file.Dark {
+					theme.Mode = "dark"
+				}
+			}
+		}
+		themes = append(themes, theme)
+	}
+	return themes, nil
+}
 
-	return os.WriteFile(lafPath, []byte(content), 0644)
+func readZipFile(r *zip.Reader, name string) ([]byte, error) {
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func detectPyCharm(config PluginConfig) bool {
+	home, err := homeDir()
+	if err != nil {
+		return false
+	}
+	matches, _ := filepath.Glob(filepath.Join(home, "Library/Application Support/JetBrains/PyCharm*"))
+	return len(matches) > 0
 }
@@ -0,0 +1,147 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Qutebrowser sets the web pages' preferred color scheme, plus any other
+// settings in custom "day", "night", or "dusk" (e.g. the UI's colors.*
+// options), in qutebrowser. A running instance gets them as :set commands
+// over its IPC socket and saves them itself; otherwise they are written to
+// autoconfig.yml for its next launch.
+func Qutebrowser(config PluginConfig) error {
+	settings := map[string]any{"colors.webpage.preferred_color_scheme": "dark"}
+	if config.IsLight {
+		settings["colors.webpage.preferred_color_scheme"] = "light"
+	}
+	maps.Copy(settings, config.GetModeSettings())
+
+	if onPath("qutebrowser") && exec.Command("pgrep", "-x", "qutebrowser").Run() == nil {
+		// Commands passed to qutebrowser run in the running instance.
+		var commands []string
+		for _, name := range slices.Sorted(maps.Keys(settings)) {
+			value, ok := settings[name].(string)
+			if !ok {
+				// :set parses values as YAML, which JSON is.
+				data, err := json.Marshal(settings[name])
+				if err != nil {
+					return err
+				}
+				value = string(data)
+			}
+			commands = append(commands, fmt.Sprintf(":set %s %s", name, value))
+		}
+		if output, err := run(exec.Command("qutebrowser", commands...)); err != nil {
+			return fmt.Errorf("qutebrowser failed: %w: %s", err, output)
+		}
+		return nil
+	}
+
+	path, err := qutebrowserAutoconfig()
+	if err != nil {
+		return err
+	}
+	return updateQutebrowserAutoconfig(path, settings)
+}
+
+// updateQutebrowserAutoconfig sets settings globally in autoconfig.yml,
+// keeping its comments, other settings, and per-URL values.
+func updateQutebrowserAutoconfig(path string, settings map[string]any) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+		yamlSet(doc.Content[0], "config_version", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "2"})
+	}
+	root := doc.Content[0]
+
+	section := yamlLookup(root, "settings")
+	if section == nil || section.Kind != yaml.MappingNode {
+		section = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		yamlSet(root, "settings", section)
+	}
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		var value yaml.Node
+		if err := value.Encode(settings[name]); err != nil {
+			return err
+		}
+		// Keep the setting's per-URL values.
+		setting := yamlLookup(section, name)
+		if setting == nil {
+			setting = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			yamlSet(section, name, setting)
+		}
+		yamlSet(setting, "global", &value)
+	}
+
+	var output bytes.Buffer
+	enc := yaml.NewEncoder(&output)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := writeFile(path, output.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// yamlLookup returns the value of key in a mapping node, or nil.
+func yamlLookup(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlSet replaces the value of key in a mapping node, or appends it.
+func yamlSet(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func qutebrowserAutoconfig() (string, error) {
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, ".qutebrowser", "autoconfig.yml"), nil
+	}
+	return filepath.Join(home, ".config", "qutebrowser", "autoconfig.yml"), nil
+}
+
+func detectQutebrowser(config PluginConfig) bool {
+	path, err := qutebrowserAutoconfig()
+	return onPath("qutebrowser") || (err == nil && exists(filepath.Dir(path)))
+}
@@ -0,0 +1,65 @@
+package plugins
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// WindowsRegistry writes arbitrary Windows registry values through reg.exe, on
+// Windows or from inside WSL, for apps that only keep their theme there.
+// Custom "light_values" and "dark_values" list the values for each mode,
+// each with a path, name, and value. "hive" defaults to HKCU. The value's
+// YAML type picks REG_DWORD for numbers and booleans and REG_SZ otherwise;
+// "type" overrides it.
+func WindowsRegistry(config PluginConfig) error {
+	values, err := config.modeValues()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		path, _ := v["path"].(string)
+		name, _ := v["name"].(string)
+		if _, ok := v["value"]; !ok || path == "" || name == "" {
+			return fmt.Errorf("registry value %v: path, name, and value are required", v)
+		}
+
+		hive, _ := v["hive"].(string)
+		if hive == "" {
+			hive = "HKCU"
+		}
+		key := hive + `\` + strings.Trim(path, `\`)
+
+		value := fmt.Sprint(v["value"])
+		typ, _ := v["type"].(string)
+		switch b := v["value"].(type) {
+		case bool:
+			value = "0"
+			if b {
+				value = "1"
+			}
+			if typ == "" {
+				typ = "REG_DWORD"
+			}
+		case int:
+			if typ == "" {
+				typ = "REG_DWORD"
+			}
+		}
+		if typ == "" {
+			typ = "REG_SZ"
+		}
+
+		cmd := exec.Command("reg.exe", "add", key, "/v", name, "/t", typ, "/d", value, "/f")
+		if output, err := run(cmd); err != nil {
+			return fmt.Errorf("reg.exe add %s %s failed: %w: %s", key, name, err, output)
+		}
+	}
+	return nil
+}
+
+func detectRegistry(config PluginConfig) bool {
+	return runtime.GOOS == "windows" || detectWSL(config)
+}
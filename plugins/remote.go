@@ -0,0 +1,40 @@
+package plugins
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Remote runs the Day or Night shell command on another machine over SSH,
+// so configs there (tmux, vim, bat) follow the local mode. Custom "host" is
+// an SSH destination or ~/.ssh/config alias; key-based auth is required
+// because scheduled runs cannot answer prompts.
+func Remote(config PluginConfig) error {
+	command := config.Night
+	if config.IsLight {
+		command = config.Day
+	}
+
+	if command == "" {
+		mode := "night"
+		if config.IsLight {
+			mode = "day"
+		}
+		return fmt.Errorf("missing %s command configuration", mode)
+	}
+
+	host, _ := config.Custom["host"].(string)
+	if host == "" {
+		return fmt.Errorf("missing host configuration")
+	}
+
+	cmd := exec.Command("ssh",
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=10",
+		host, command)
+	if output, err := run(cmd); err != nil {
+		return fmt.Errorf("ssh %s failed: %w: %s", host, err, output)
+	}
+
+	return nil
+}
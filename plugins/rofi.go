@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rofi switches the launcher theme. For rofi, Day and Night are theme names
+// or .rasi paths written to the @theme line of config.rasi. With custom
+// "launcher: wofi" they are stylesheet paths imported from wofi's style.css.
+func Rofi(config PluginConfig) error {
+	theme := config.Night
+	if config.IsLight {
+		theme = config.Day
+	}
+
+	if theme == "" {
+		mode := "night"
+		if config.IsLight {
+			mode = "day"
+		}
+		return fmt.Errorf("missing %s theme configuration", mode)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return err
+	}
+
+	if launcher, _ := config.Custom["launcher"].(string); launcher == "wofi" {
+		stylePath, err := ExpandPath(theme)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(stylePath); err != nil {
+			return fmt.Errorf("wofi stylesheet not found: %w", err)
+		}
+
+		content := fmt.Sprintf(`/* Auto-generated by day-night-cycle */
+@import url("%s");
+`, stylePath)
+
+		path := filepath.Join(home, ".config/wofi/style.css")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return writeFile(path, []byte(content), 0644)
+	}
+
+	path := filepath.Join(home, ".config/rofi/config.rasi")
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	themeLine := fmt.Sprintf("@theme %q", theme)
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	replaced := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "@theme") {
+			lines[i] = themeLine
+			replaced = true
+		}
+	}
+	if !replaced {
+		// rofi only honors @theme outside of blocks; the end of the file is
+		// always at top level.
+		lines = append(lines, themeLine)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFile(path, []byte(strings.TrimLeft(strings.Join(lines, "\n"), "\n")+"\n"), 0644)
+}
+
+func detectRofi(config PluginConfig) bool {
+	if launcher, _ := config.Custom["launcher"].(string); launcher == "wofi" {
+		return onPath("wofi")
+	}
+	return onPath("rofi")
+}
@@ -0,0 +1,30 @@
+package plugins
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Shortcuts runs the Apple Shortcut named by Day or Night with the
+// shortcuts CLI (macOS 12+), so anything Shortcuts automates can follow the
+// mode. An empty name runs nothing in that mode.
+func Shortcuts(config PluginConfig) error {
+	name := config.Night
+	if config.IsLight {
+		name = config.Day
+	}
+	if name == "" {
+		return nil
+	}
+
+	if output, err := run(exec.Command("shortcuts", "run", name)); err != nil {
+		return fmt.Errorf("shortcuts run %q failed: %w: %s", name, err, output)
+	}
+	return nil
+}
+
+// detectShortcuts checks for the shortcuts CLI.
+func detectShortcuts(config PluginConfig) bool {
+	_, err := exec.LookPath("shortcuts")
+	return err == nil
+}
@@ -1,8 +1,12 @@
 package plugins
 
 import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func Sublime(config PluginConfig) error {
@@ -18,7 +22,7 @@ func Sublime(config PluginConfig) error {
 		colorScheme = defaultScheme
 	}
 
-	home, err := os.UserHomeDir()
+	home, err := homeDir()
 	if err != nil {
 		return err
 	}
@@ -40,3 +44,87 @@ func Sublime(config PluginConfig) error {
 
 	return lastErr
 }
+
+func captureSublime(config PluginConfig, isLight bool) (day, night string, err error) {
+	home, err := homeDir()
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, dir := range []string{"Sublime Text", "Sublime Text 4", "Sublime Text 3"} {
+		settingsPath := filepath.Join(home, "Library/Application Support", dir, "Packages/User/Preferences.sublime-settings")
+		if !exists(settingsPath) {
+			continue
+		}
+		settings, err := readJSONSettings(settingsPath)
+		if err != nil {
+			return "", "", err
+		}
+		scheme, _ := settings["color_scheme"].(string)
+		return forMode(scheme, isLight)
+	}
+	return "", "", fmt.Errorf("Sublime Text preferences not found")
+}
+
+// listSublime lists the color schemes in Sublime Text's bundled and
+// installed packages and its Packages folder, by file name, which
+// color_scheme takes.
+func listSublime(config PluginConfig) ([]Theme, error) {
+	home, err := homeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	packages, _ := filepath.Glob("/Applications/Sublime Text.app/Contents/MacOS/Packages/*.sublime-package")
+	for _, dir := range []string{"Sublime Text", "Sublime Text 4", "Sublime Text 3"} {
+		installed, _ := filepath.Glob(filepath.Join(home, "Library/Application Support", dir, "Installed Packages/*.sublime-package"))
+		packages = append(packages, installed...)
+
+		loose := filepath.Join(home, "Library/Application Support", dir, "Packages")
+		if !exists(loose) {
+			continue
+		}
+		err := filepath.WalkDir(loose, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			names = append(names, filepath.Base(path))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", loose, err)
+		}
+	}
+	for _, path := range packages {
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		for _, f := range r.File {
+			names = append(names, filepath.Base(f.Name))
+		}
+		r.Close()
+	}
+
+	var themes []Theme
+	for _, name := range names {
+		if strings.HasSuffix(name, ".sublime-color-scheme") || strings.HasSuffix(name, ".tmTheme") {
+			themes = append(themes, Theme{Name: name})
+		}
+	}
+	return themes, nil
+}
+
+func detectSublime(config PluginConfig) bool {
+	home, err := homeDir()
+	if err != nil {
+		return false
+	}
+	for _, dir := range []string{"Sublime Text", "Sublime Text 4", "Sublime Text 3"} {
+		if exists(filepath.Join(home, "Library/Application Support", dir)) {
+			return true
+		}
+	}
+	return false
+}
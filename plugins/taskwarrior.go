@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Taskwarrior points the theme include line of .taskrc at Day or Night, a
+// theme file name like "light-256.theme" or a path. Custom
+// "light_timewarrior" and "dark_timewarrior" do the same for the theme
+// import of timewarrior.cfg. Both are read on every command.
+func Taskwarrior(config PluginConfig) error {
+	theme, key := config.Night, "dark_timewarrior"
+	if config.IsLight {
+		theme, key = config.Day, "light_timewarrior"
+	}
+	timewTheme, _ := config.Custom[key].(string)
+	if theme == "" && timewTheme == "" {
+		return fmt.Errorf("set day and night to taskwarrior themes, or custom %s for timewarrior", key)
+	}
+
+	if theme != "" {
+		path, err := taskrcPath()
+		if err != nil {
+			return err
+		}
+		if theme, err = ExpandPath(theme); err != nil {
+			return err
+		}
+		if err := SetConfigLine(path, themeLine("include"), "include "+theme); err != nil {
+			return err
+		}
+	}
+
+	if timewTheme != "" {
+		dir, err := timewarriorDir()
+		if err != nil {
+			return err
+		}
+		if timewTheme, err = ExpandPath(timewTheme); err != nil {
+			return err
+		}
+		if err := SetConfigLine(filepath.Join(dir, "timewarrior.cfg"), themeLine("import"), "import "+timewTheme); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// themeLine matches a directive that loads a .theme file, leaving the
+// file's other includes alone.
+func themeLine(directive string) func([]string) bool {
+	return func(fields []string) bool {
+		return len(fields) == 2 && fields[0] == directive && strings.HasSuffix(fields[1], ".theme")
+	}
+}
+
+// taskrcPath returns $TASKRC, or taskwarrior 3's XDG location when it
+// exists, or ~/.taskrc.
+func taskrcPath() (string, error) {
+	if path := os.Getenv("TASKRC"); path != "" {
+		return path, nil
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	if path := filepath.Join(home, ".config/task/taskrc"); exists(path) {
+		return path, nil
+	}
+	return filepath.Join(home, ".taskrc"), nil
+}
+
+// timewarriorDir returns $TIMEWARRIORDB, or ~/.timewarrior when it exists,
+// or the XDG location.
+func timewarriorDir() (string, error) {
+	if dir := os.Getenv("TIMEWARRIORDB"); dir != "" {
+		return dir, nil
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	if dir := filepath.Join(home, ".timewarrior"); exists(dir) {
+		return dir, nil
+	}
+	return filepath.Join(home, ".config/timewarrior"), nil
+}
+
+func detectTaskwarrior(config PluginConfig) bool {
+	return onPath("task") || onPath("timew")
+}
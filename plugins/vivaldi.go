@@ -0,0 +1,50 @@
+package plugins
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+)
+
+// vivaldiProcess is Vivaldi's process name, which must not be running
+// while its Preferences change: it rewrites them on exit.
+var vivaldiProcess = map[string]string{"darwin": "Vivaldi", "linux": "vivaldi-bin"}[runtime.GOOS]
+
+// Vivaldi switches Vivaldi's current theme between the theme IDs in Day and
+// Night, found under vivaldi.themes in its Preferences file, and turns off
+// its own theme schedule so it doesn't switch back. The change applies at
+// Vivaldi's next launch, so the plugin waits for Vivaldi to quit.
+func Vivaldi(config PluginConfig) error {
+	theme := config.Night
+	if config.IsLight {
+		theme = config.Day
+	}
+	if theme == "" {
+		return fmt.Errorf("set day and night to Vivaldi theme IDs, e.g. Vivaldi4 and Vivaldi4Dark")
+	}
+
+	path, err := vivaldiPreferences()
+	if err != nil {
+		return err
+	}
+	return UpdateNestedJSON(path, map[string]any{
+		"vivaldi.themes.current":         theme,
+		"vivaldi.theme.schedule.enabled": false,
+	})
+}
+
+func vivaldiPreferences() (string, error) {
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library/Application Support/Vivaldi/Default/Preferences"), nil
+	}
+	return filepath.Join(home, ".config/vivaldi/Default/Preferences"), nil
+}
+
+func detectVivaldi(config PluginConfig) bool {
+	path, err := vivaldiPreferences()
+	return err == nil && exists(path)
+}
@@ -0,0 +1,150 @@
+package plugins
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Wallpaper sets the macOS desktop picture. Day and Night apply to every
+// display; the light_displays and dark_displays lists override individual
+// displays in System Events order (an empty entry leaves that display alone).
+// A directory makes macOS rotate through its images, or with custom "pick"
+// sets one of them at each transition. Dynamic HEIC files are passed
+// through as-is so macOS keeps animating them.
+func Wallpaper(config PluginConfig) error {
+	path := config.Night
+	displaysKey := "dark_displays"
+	if config.IsLight {
+		path = config.Day
+		displaysKey = "light_displays"
+	}
+
+	displays, _ := config.Custom[displaysKey].([]any)
+	pick, _ := config.Custom["pick"].(string)
+
+	if path == "" && len(displays) == 0 {
+		mode := "night"
+		if config.IsLight {
+			mode = "day"
+		}
+		return fmt.Errorf("missing %s wallpaper configuration", mode)
+	}
+
+	if path != "" {
+		image, err := pickImage(path, pick)
+		if err != nil {
+			return err
+		}
+		if err := setWallpaper("every desktop", image); err != nil {
+			return err
+		}
+	}
+
+	for i, display := range displays {
+		p, ok := display.(string)
+		if !ok {
+			return fmt.Errorf("%s[%d]: expected a path, got %v", displaysKey, i, display)
+		}
+		if p == "" {
+			continue
+		}
+		image, err := pickImage(p, pick)
+		if err != nil {
+			return fmt.Errorf("display %d: %w", i+1, err)
+		}
+		if err := setWallpaper(fmt.Sprintf("desktop %d", i+1), image); err != nil {
+			return fmt.Errorf("display %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// setWallpaper points a System Events desktop target ("every desktop",
+// "desktop 2") at an image file or a folder of images. An image for every
+// desktop goes through the native helper when it is built in.
+func setWallpaper(target, path string) error {
+	fullPath, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return fmt.Errorf("wallpaper not found: %w", err)
+	}
+
+	if target == "every desktop" && !info.IsDir() {
+		if handled, err := nativeSetDesktopImage(fullPath); handled {
+			return err
+		}
+	}
+
+	action := fmt.Sprintf(`set picture to POSIX file "%s"`, fullPath)
+	if info.IsDir() {
+		action = fmt.Sprintf(`set pictures folder to POSIX file "%s"
+			set picture rotation to 1`, fullPath)
+	}
+
+	script := fmt.Sprintf(`
+tell application "System Events"
+	tell %s
+		%s
+	end tell
+end tell
+`, target, action)
+
+	cmd := exec.Command("osascript", "-e", script)
+	if output, err := run(cmd); err != nil {
+		return fmt.Errorf("osascript failed: %w: %s", err, output)
+	}
+
+	return nil
+}
+
+// imageExts are the picture types taken from a folder by pickImage.
+var imageExts = []string{".jpg", ".jpeg", ".png", ".heic", ".tif", ".tiff", ".gif", ".webp"}
+
+// pickImage returns path, or when pick is set and path is a folder, one of
+// its images: "random", or "sequential", which steps through them in name
+// order one per day, so each day's transition shows the next.
+func pickImage(path, pick string) (string, error) {
+	if pick == "" {
+		return path, nil
+	}
+	dir, err := ExpandPath(path)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return path, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var images []string
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.IsDir() && slices.Contains(imageExts, ext) {
+			images = append(images, filepath.Join(dir, entry.Name()))
+		}
+	}
+	if len(images) == 0 {
+		return "", fmt.Errorf("no images in %s", dir)
+	}
+
+	if pick == "sequential" {
+		now := time.Now()
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+		return images[day%int64(len(images))], nil
+	}
+	return images[rand.IntN(len(images))], nil
+}
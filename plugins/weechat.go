@@ -0,0 +1,94 @@
+package plugins
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// Weechat applies WeeChat options in custom "day", "night", or "dusk" with
+// /set, then the command in Day or Night, if any (e.g. to load a theme
+// script). Running clients get them live through the fifo plugin's pipe;
+// without one, weechat-headless applies and saves them for the next start.
+func Weechat(config PluginConfig) error {
+	settings := config.GetModeSettings()
+	var commands []string
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		commands = append(commands, fmt.Sprintf("/set %s %v", name, settings[name]))
+	}
+	command := config.Night
+	if config.IsLight {
+		command = config.Day
+	}
+	if command != "" {
+		commands = append(commands, command)
+	}
+	if len(commands) == 0 {
+		return fmt.Errorf("set options under custom day and night, or a command in day and night")
+	}
+
+	sent := false
+	for _, fifo := range weechatFIFOs() {
+		// Without O_NONBLOCK, a pipe left behind by a crashed client blocks.
+		f, err := os.OpenFile(fifo, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			continue
+		}
+		var b strings.Builder
+		for _, c := range commands {
+			b.WriteString("*" + c + "\n")
+		}
+		_, err = f.WriteString(b.String())
+		f.Close()
+		changed = true
+		if err != nil {
+			return fmt.Errorf("writing to %s: %w", fifo, err)
+		}
+		sent = true
+	}
+	if sent {
+		return nil
+	}
+
+	if !onPath("weechat-headless") {
+		return fmt.Errorf("WeeChat isn't running with its fifo plugin, and weechat-headless isn't installed to apply the options offline")
+	}
+	commands = append(commands, "/save", "/quit")
+	cmd := exec.Command("weechat-headless", "--no-connect", "--run-command", strings.Join(commands, ";"))
+	if output, err := run(cmd); err != nil {
+		return fmt.Errorf("weechat-headless failed: %w: %s", err, output)
+	}
+	return nil
+}
+
+// weechatFIFOs returns the fifo plugin's pipes, in the places WeeChat
+// versions put them.
+func weechatFIFOs() []string {
+	home, err := homeDir()
+	if err != nil {
+		return nil
+	}
+	patterns := []string{
+		filepath.Join(home, ".weechat/weechat_fifo*"),
+		filepath.Join(home, ".local/share/weechat/weechat_fifo*"),
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		patterns = append(patterns, filepath.Join(dir, "weechat/weechat_fifo*"))
+	}
+
+	var fifos []string
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		fifos = append(fifos, matches...)
+	}
+	return fifos
+}
+
+func detectWeechat(config PluginConfig) bool {
+	return onPath("weechat") || onPath("weechat-headless")
+}
@@ -0,0 +1,41 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// WSL flips the Windows host's app and system theme from inside WSL through
+// reg.exe interop, so one schedule drives both environments. Set custom
+// "system: false" to leave the taskbar and Start menu alone.
+func WSL(config PluginConfig) error {
+	if !detectWSL(config) {
+		return fmt.Errorf("not running inside WSL")
+	}
+
+	value := "0"
+	if config.IsLight {
+		value = "1"
+	}
+
+	names := []string{"AppsUseLightTheme"}
+	if system, ok := config.Custom["system"].(bool); !ok || system {
+		names = append(names, "SystemUsesLightTheme")
+	}
+
+	for _, name := range names {
+		cmd := exec.Command("reg.exe", "add",
+			`HKCU\Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`,
+			"/v", name, "/t", "REG_DWORD", "/d", value, "/f")
+		if output, err := run(cmd); err != nil {
+			return fmt.Errorf("reg.exe failed: %w: %s", err, output)
+		}
+	}
+
+	return nil
+}
+
+func detectWSL(config PluginConfig) bool {
+	return os.Getenv("WSL_DISTRO_NAME") != "" || exists("/proc/sys/fs/binfmt_misc/WSLInterop")
+}
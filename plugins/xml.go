@@ -0,0 +1,233 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// xmlStep is one step of an element path: an element name, optionally
+// narrowed to the element whose attribute attr is value.
+type xmlStep struct {
+	name, attr, value string
+}
+
+var xmlStepPattern = regexp.MustCompile(`^([\w.:-]+)(?:\[@([\w.:-]+)="([^"]*)"\])?$`)
+
+// parseXMLPath splits a path like
+// `application/component[@name="LafManager"]/laf` into its steps.
+func parseXMLPath(element string) ([]xmlStep, error) {
+	var steps []xmlStep
+	for part := range strings.SplitSeq(element, "/") {
+		m := xmlStepPattern.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("invalid XML path step %q", part)
+		}
+		steps = append(steps, xmlStep{m[1], m[2], m[3]})
+	}
+	return steps, nil
+}
+
+func (s xmlStep) matches(start xml.StartElement) bool {
+	if xmlName(start.Name) != s.name {
+		return false
+	}
+	if s.attr == "" {
+		return true
+	}
+	for _, a := range start.Attr {
+		if xmlName(a.Name) == s.attr {
+			return a.Value == s.value
+		}
+	}
+	return false
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+// xmlElement is where an element on the path sits in the file.
+type xmlElement struct {
+	start   xml.StartElement
+	from    int64 // Offset of the start tag
+	to      int64 // Offset just past the start tag
+	closing int64 // Offset of the end tag
+}
+
+func (e xmlElement) selfClosing(data []byte) bool {
+	return bytes.HasSuffix(data[:e.to], []byte("/>"))
+}
+
+// UpdateXMLAttributes sets attributes on the element at path within an
+// XML file, rewriting only that element's start tag so comments,
+// formatting, and every other element and attribute survive. Path steps
+// are separated by "/" and may pick an element by an attribute, as in
+// `application/component[@name="LafManager"]/laf`. Missing elements are
+// created, with the attributes their steps select, and a missing file is
+// created.
+func UpdateXMLAttributes(path, element string, updates map[string]string) error {
+	steps, err := parseXMLPath(element)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// found holds the deepest elements matched along the path, found[i]
+	// for steps[i].
+	var found []xmlElement
+	var stack []bool // Whether each open element is on the path
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for len(found) < len(steps) {
+		from := d.InputOffset()
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			onPath := len(stack) == len(found) && steps[len(found)].matches(tok)
+			if onPath {
+				found = append(found, xmlElement{start: tok.Copy(), from: from, to: d.InputOffset()})
+			}
+			stack = append(stack, onPath)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return fmt.Errorf("failed to parse %s: unexpected </%s>", path, xmlName(tok.Name))
+			}
+			if stack[len(stack)-1] {
+				found[len(stack)-1].closing = from
+				// Past the deepest match: its missing children go here.
+				if len(stack) == len(found) {
+					return writeXMLUpdate(path, data, steps, found, updates)
+				}
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	switch {
+	case len(found) == 0 && len(data) > 0:
+		return fmt.Errorf("failed to update %s: no <%s> root element", path, steps[0].name)
+	case len(found) > 0 && len(found) < len(steps):
+		return fmt.Errorf("failed to parse %s: <%s> is never closed", path, steps[len(found)-1].name)
+	}
+	return writeXMLUpdate(path, data, steps, found, updates)
+}
+
+// writeXMLUpdate writes data with the element at the end of steps
+// updated: its start tag rewritten when found holds all of steps, or the
+// missing elements inserted into the deepest one found.
+func writeXMLUpdate(path string, data []byte, steps []xmlStep, found []xmlElement, updates map[string]string) error {
+	var output []byte
+	if len(found) == len(steps) {
+		target := found[len(found)-1]
+		tag := startTag(xmlName(target.start.Name), updatedAttrs(target.start.Attr, updates), target.selfClosing(data))
+		output = slices.Concat(data[:target.from], []byte(tag), data[target.to:])
+	} else if len(found) == 0 {
+		output = []byte(xmlChain(steps, updates, "") + "\n")
+	} else {
+		parent := found[len(found)-1]
+		indent, _ := lineIndent(data, parent.from)
+		chain := xmlChain(steps[len(found):], updates, indent+"  ")
+		if parent.selfClosing(data) {
+			// A self-closing parent gains an end tag to hold the children.
+			name := xmlName(parent.start.Name)
+			tag := startTag(name, parent.start.Attr, false)
+			output = slices.Concat(data[:parent.from], []byte(tag+"\n"+indent+"  "+chain+"\n"+indent+"</"+name+">"), data[parent.to:])
+		} else {
+			// An end tag on its own line keeps its place below the children.
+			insert := chain
+			if closingIndent, ok := lineIndent(data, parent.closing); ok {
+				insert = "  " + chain + "\n" + closingIndent
+			}
+			output = slices.Concat(data[:parent.closing], []byte(insert), data[parent.closing:])
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := writeFile(path, output, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// updatedAttrs returns attrs with updates applied in place, and the
+// attributes they add appended in name order.
+func updatedAttrs(attrs []xml.Attr, updates map[string]string) []xml.Attr {
+	remaining := maps.Clone(updates)
+	result := slices.Clone(attrs)
+	for i, a := range result {
+		if v, ok := remaining[xmlName(a.Name)]; ok {
+			result[i].Value = v
+			delete(remaining, xmlName(a.Name))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(remaining)) {
+		result = append(result, xml.Attr{Name: xml.Name{Local: name}, Value: remaining[name]})
+	}
+	return result
+}
+
+func startTag(name string, attrs []xml.Attr, selfClosing bool) string {
+	var b strings.Builder
+	b.WriteString("<" + name)
+	for _, a := range attrs {
+		b.WriteString(" " + xmlName(a.Name) + `="`)
+		xml.EscapeText(&b, []byte(a.Value))
+		b.WriteString(`"`)
+	}
+	if selfClosing {
+		b.WriteString(" />")
+	} else {
+		b.WriteString(">")
+	}
+	return b.String()
+}
+
+// xmlChain renders the elements of steps nested in one another, the last
+// carrying updates, for a file whose lines at this depth start with
+// indent. The first line is left for the caller to indent.
+func xmlChain(steps []xmlStep, updates map[string]string, indent string) string {
+	step := steps[0]
+	var attrs []xml.Attr
+	if step.attr != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: step.attr}, Value: step.value})
+	}
+	if len(steps) == 1 {
+		return startTag(step.name, updatedAttrs(attrs, updates), true)
+	}
+	return startTag(step.name, attrs, false) + "\n" +
+		indent + "  " + xmlChain(steps[1:], updates, indent+"  ") + "\n" +
+		indent + "</" + step.name + ">"
+}
+
+// lineIndent returns the whitespace that starts the line holding offset.
+// ok is false when other text precedes offset on that line.
+func lineIndent(data []byte, offset int64) (indent string, ok bool) {
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	if len(bytes.Trim(data[start:offset], " \t")) > 0 {
+		return "", false
+	}
+	return string(data[start:offset]), true
+}
@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Yazi sets the flavor in yazi's theme.toml to Day or Night, as both its
+// dark and light flavor so yazi's own detection of the terminal background
+// can't pick the other. Custom "light_ranger" and "dark_ranger" set
+// ranger's colorscheme in rc.conf. Both apply at the next start.
+func Yazi(config PluginConfig) error {
+	flavor, key := config.Night, "dark_ranger"
+	if config.IsLight {
+		flavor, key = config.Day, "light_ranger"
+	}
+	colorscheme, _ := config.Custom[key].(string)
+	if flavor == "" && colorscheme == "" {
+		return fmt.Errorf("set day and night to yazi flavors, or custom %s for ranger", key)
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return err
+	}
+
+	if flavor != "" {
+		dir, err := yaziDir()
+		if err != nil {
+			return err
+		}
+		quoted := strconv.Quote(flavor)
+		if err := UpdateINISettings(filepath.Join(dir, "theme.toml"), "flavor", map[string]string{"dark": quoted, "light": quoted}); err != nil {
+			return err
+		}
+	}
+
+	if colorscheme != "" {
+		if err := setRangerColorscheme(filepath.Join(home, ".config/ranger/rc.conf"), colorscheme); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setRangerColorscheme replaces the "set colorscheme" line of ranger's
+// rc.conf, or appends one.
+func setRangerColorscheme(path, colorscheme string) error {
+	return SetConfigLine(path, func(fields []string) bool {
+		return len(fields) >= 2 && fields[0] == "set" && fields[1] == "colorscheme"
+	}, "set colorscheme "+colorscheme)
+}
+
+// yaziDir returns $YAZI_CONFIG_HOME or ~/.config/yazi.
+func yaziDir() (string, error) {
+	if dir := os.Getenv("YAZI_CONFIG_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config/yazi"), nil
+}
+
+// listYazi lists the flavors installed in yazi's flavors directory.
+func listYazi(config PluginConfig) ([]Theme, error) {
+	dir, err := yaziDir()
+	if err != nil {
+		return nil, err
+	}
+	flavors, _ := filepath.Glob(filepath.Join(dir, "flavors/*.yazi"))
+	var themes []Theme
+	for _, path := range flavors {
+		themes = append(themes, Theme{Name: strings.TrimSuffix(filepath.Base(path), ".yazi")})
+	}
+	return themes, nil
+}
+
+func detectYazi(config PluginConfig) bool {
+	return onPath("yazi") || onPath("ranger")
+}
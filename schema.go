@@ -0,0 +1,10 @@
+// Package daynightcycle holds files from the repository root that the
+// command embeds.
+package daynightcycle
+
+import _ "embed"
+
+// ConfigSchema is the JSON Schema for config.yaml.
+//
+//go:embed config.schema.json
+var ConfigSchema []byte